@@ -2,27 +2,28 @@ package main
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
-	"strings"
+	"syscall"
 
+	"github.com/0x222fe/codecrafters-redis-go/internal/aof"
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
 	"github.com/0x222fe/codecrafters-redis-go/internal/command"
 	"github.com/0x222fe/codecrafters-redis-go/internal/config"
 	"github.com/0x222fe/codecrafters-redis-go/internal/connection"
 	"github.com/0x222fe/codecrafters-redis-go/internal/handler"
 	"github.com/0x222fe/codecrafters-redis-go/internal/rdb"
+	"github.com/0x222fe/codecrafters-redis-go/internal/replication"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
 	"github.com/0x222fe/codecrafters-redis-go/internal/user"
-	"github.com/0x222fe/codecrafters-redis-go/internal/utils/resputil"
 )
 
 func main() {
@@ -40,6 +41,9 @@ func main() {
 		os.Exit(1)
 	}
 
+	go handleShutdown(state)
+	state.StartActiveExpire()
+
 	l, err := net.Listen("tcp", "0.0.0.0:"+strconv.Itoa(cfg.Port))
 	if err != nil {
 		fmt.Printf("Failed to bind to port %d\r\n", cfg.Port)
@@ -58,34 +62,38 @@ func main() {
 	}
 }
 
+func handleShutdown(s *state.AppState) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+
+	fmt.Println("Received shutdown signal, flushing AOF...")
+	if err := s.CloseAOF(); err != nil {
+		fmt.Printf("Failed to close AOF: %s\n", err.Error())
+	}
+	os.Exit(0)
+}
+
 func initRedis(cfg *config.Config) (*state.AppState, error) {
 	var r *rdb.RDB
 	var err error
 
-	if cfg.Dbfilename != "" {
+	aofDir := filepath.Join(cfg.Dir, cfg.AppendDirname)
+	//INFO: the AOF is the more complete dataset, so it wins over the RDB
+	loadFromAOF := cfg.AppendOnly && aof.Exists(aofDir, cfg.AppendFilename)
+
+	if cfg.Dbfilename != "" && !loadFromAOF {
 		filename := filepath.Join(cfg.Dir, cfg.Dbfilename)
 		r, err = rdb.ReadRDBFile(filename)
-		if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			r = nil
+		} else if err != nil {
 			fmt.Printf("Failed to read RDB file: %s\n", err.Error())
 			r = nil
 		}
 	}
 
-	if cfg.AppendOnly {
-		path := filepath.Join(cfg.Dir, cfg.AppendDirname)
-		if err := os.MkdirAll(path, 0755); err != nil {
-			return nil, fmt.Errorf("create dir %s: %w", path, err)
-		}
-
-		file := filepath.Join(path, cfg.AppendFilename+".1.incr.aof")
-		f, err := os.OpenFile(file, os.O_CREATE, 0644)
-		if err != nil {
-			return nil, err
-		}
-		f.Close()
-	}
-
-	store := r.MapToStore()
+	dbs := r.MapToStores(cfg.Databases)
 
 	isReplica := cfg.MasterHost != "" && cfg.MasterPort != 0
 
@@ -93,20 +101,63 @@ func initRedis(cfg *config.Config) (*state.AppState, error) {
 		&state.ReplicaState{
 			IsReplica:           isReplica,
 			MasterReplicationID: "",
-			ReplicationID:       "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb", //INFO: hardcoded for now
+			ReplicationID:       state.NewReplicationID(),
 			ReplicationOffset:   0,
-		}, cfg, store)
+			SecondReplOffset:    -1,
+		}, cfg, dbs)
 
-	if isReplica {
-		err = initRepHandshake(state)
+	if cfg.AppendOnly {
+		policy, err := aof.ParseFsyncPolicy(cfg.AppendFsync)
 		if err != nil {
-			return nil, fmt.Errorf("failed to initialize replication handshake: %w", err)
+			return nil, err
+		}
+
+		if loadFromAOF {
+			err = loadAOF(state, aofDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load AOF: %w", err)
+			}
 		}
+
+		a, err := aof.Open(aofDir, cfg.AppendFilename, policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open AOF: %w", err)
+		}
+		state.SetAOF(a)
+	}
+
+	link := replication.NewLink(state, handler.RunCommand)
+	state.SetReplicationController(link)
+	if isReplica {
+		link.ReplicaOf(cfg.MasterHost, cfg.MasterPort)
 	}
 
 	return state, nil
 }
 
+func loadAOF(s *state.AppState, dir string) error {
+	cfg := s.ReadCfg()
+
+	c := client.NewClient(context.Background(), connection.NewDiscardConnection())
+	c.Propagated = true
+	c.Loading = true
+
+	return aof.Load(dir, cfg.AppendFilename, aof.LoadOptions{
+		AllowTruncated: cfg.AOFLoadTruncated,
+		LoadRDB: func(r io.Reader) error {
+			data, err := rdb.ParseRDB(r)
+			if err != nil {
+				return err
+			}
+			s.SetDBs(data.MapToStores(s.DBCount()))
+			return nil
+		},
+		Exec: func(cmd command.Command) error {
+			return handler.RunCommand(c, s, cmd)
+		},
+	})
+}
+
 func handleConnection(rawConn net.Conn, s *state.AppState) {
 	defer rawConn.Close()
 
@@ -118,6 +169,8 @@ func handleConnection(rawConn net.Conn, s *state.AppState) {
 
 	defer func() {
 		s.RemoveReplica(conn.ID)
+		s.Unwatch(conn.ID)
+		s.RemoveSubscriber(conn.ID)
 	}()
 
 	reader := bufio.NewReader(rawConn)
@@ -131,6 +184,11 @@ func handleConnection(rawConn net.Conn, s *state.AppState) {
 				fmt.Printf("Connection closed by client: %s\n", rawConn.RemoteAddr().String())
 				return
 			}
+			// INFO: the server closed the connection, e.g. a subscriber over
+			// its output buffer limit
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
 
 			rawConn.Write(resp.NewError(err).Bytes())
 			continue
@@ -150,189 +208,3 @@ func handleConnection(rawConn net.Conn, s *state.AppState) {
 		}
 	}
 }
-
-func serveMaster(appState *state.AppState, rawConn net.Conn, reader *bufio.Reader) {
-	defer func() {
-		defer rawConn.Close()
-		fmt.Println("Master connection closed")
-	}()
-
-	defaultUser, _ := appState.GetUser(user.DefaultUserName)
-	conn := connection.NewConnection(rawConn, defaultUser)
-
-	c := client.NewClient(context.Background(), conn)
-	c.Propagated = true
-	for {
-		respVal, bytesRead, err := resp.DecodeRESPInputExact(reader, resp.RESPArr)
-		if err != nil {
-			if err == io.EOF {
-				fmt.Println("Lost connection to master")
-				return
-			}
-
-			fmt.Printf("Error reading from master: %s\n", err.Error())
-			continue
-		}
-
-		cmd, err := command.ParseCommandFromRESP(respVal)
-		if err != nil {
-			fmt.Printf("Error parsing command from master: %s\n", err.Error())
-			continue
-		}
-		fmt.Println("Received command from master:", cmd.Name)
-
-		err = handler.RunCommand(c, appState, cmd)
-		if err != nil {
-			fmt.Printf("Error executing command from master: %s\n", err.Error())
-			continue
-		}
-
-		appState.WriteState(func(st *state.ReplicaState) {
-			st.ReplicationOffset += bytesRead
-		})
-	}
-}
-
-func initRepHandshake(appState *state.AppState) error {
-	cfg := appState.ReadCfg()
-	masterAddr := net.JoinHostPort(cfg.MasterHost, strconv.Itoa(cfg.MasterPort))
-
-	fmt.Printf("Connecting to master server at %s...\n", masterAddr)
-	conn, err := net.Dial("tcp", masterAddr)
-	if err != nil {
-		return errors.New("failed to connect to master server: " + err.Error())
-	}
-
-	handShakeOk := false
-	defer func() {
-		if !handShakeOk {
-			fmt.Printf("Handshake failed, closing connection, %s\n", conn.RemoteAddr().String())
-			conn.Close()
-		}
-	}()
-
-	reader := bufio.NewReader(conn)
-
-	pingCmd := resputil.BulkStringsToRESPArray([]string{"PING"})
-	_, err = conn.Write(pingCmd.Bytes())
-	if err != nil {
-		return fmt.Errorf("failed to send PING command: %w", err)
-	}
-
-	res, _, err := resp.DecodeRESPInputExact(reader, resp.RESPStr)
-	if err != nil {
-		return fmt.Errorf("failed to read response from master server: %w", err)
-	}
-	if val, ok := res.GetStringValue(); !ok || val != "PONG" {
-		return errors.New("unexpected response from master server, expected 'PONG', got: " + val)
-	}
-
-	replconfRes := resputil.BulkStringsToRESPArray([]string{"REPLCONF", "listening-port", strconv.Itoa(cfg.Port)})
-	_, err = conn.Write(replconfRes.Bytes())
-	if err != nil {
-		return fmt.Errorf("failed to send REPLCONF listening-port command: %w", err)
-	}
-
-	res, _, err = resp.DecodeRESPInputExact(reader, resp.RESPStr)
-	if err != nil {
-		return fmt.Errorf("failed to read response from master server: %w", err)
-	}
-	if val, ok := res.GetStringValue(); !ok || val != "OK" {
-		return errors.New("unexpected response from master server, expected 'OK', got: " + val)
-	}
-
-	replconfRes = resputil.BulkStringsToRESPArray([]string{"REPLCONF", "capa", "psync2"})
-	_, err = conn.Write(replconfRes.Bytes())
-	if err != nil {
-		return fmt.Errorf("failed to send REPLCONF capa command: %w", err)
-	}
-
-	res, _, err = resp.DecodeRESPInputExact(reader, resp.RESPStr)
-	if err != nil {
-		return fmt.Errorf("failed to read response from master server: %w", err)
-	}
-	if val, ok := res.GetStringValue(); !ok || val != "OK" {
-		return errors.New("unexpected response from master server, expected 'OK', got: " + val)
-	}
-
-	psyncEncoded := resputil.BulkStringsToRESPArray([]string{"PSYNC", "?", "-1"}).Bytes()
-	_, err = conn.Write(psyncEncoded)
-	if err != nil {
-		return fmt.Errorf("failed to send PSYNC command: %w", err)
-	}
-	res, _, err = resp.DecodeRESPInputExact(reader, resp.RESPStr)
-	if err != nil {
-		return fmt.Errorf("failed to read response from master server: %w", err)
-	}
-
-	content, ok := res.GetStringValue()
-	if !ok {
-		return errors.New("unexpected response from master server, expected string, got: " + res.GetType())
-	}
-
-	if !strings.HasPrefix(content, "FULLRESYNC ") {
-		return errors.New("unexpected response from master server, expected 'FULLRESYNC', got: " + content)
-	}
-
-	parts := strings.SplitN(content, " ", 3)
-
-	if len(parts) != 3 {
-		return errors.New("unexpected response format from master server, expected 'FULLRESYNC <replication_id> <offset>', got: " + content)
-	}
-
-	masterRepID := parts[1]
-	if masterRepID == "" {
-		return errors.New("replication ID cannot be empty")
-	}
-	repOffset, err := strconv.Atoi(parts[2])
-	if err != nil {
-		return fmt.Errorf("invalid replication offset: %w", err)
-	}
-
-	appState.WriteState(func(s *state.ReplicaState) {
-		s.MasterReplicationID = masterRepID
-		s.ReplicationOffset = repOffset
-	})
-
-	flag, err := reader.ReadByte()
-	if err != nil {
-		return fmt.Errorf("failed to read RDB file length: %w", err)
-	}
-	if flag != '$' {
-		return errors.New("unexpected response from master server when reading RDB file length, expected '$', got: " + string(flag))
-	}
-
-	line, err := reader.ReadString('\n')
-	if err != nil {
-		return fmt.Errorf("failed to read RDB file length: %w", err)
-	}
-
-	rdbLen, err := strconv.Atoi(strings.TrimSuffix(line, "\r\n"))
-	if err != nil {
-		return fmt.Errorf("invalid RDB file length: %w", err)
-	}
-
-	if rdbLen <= 0 {
-		return errors.New("invalid RDB file length, must be greater than 0")
-	}
-
-	rdbBytes := make([]byte, rdbLen)
-	_, err = io.ReadFull(reader, rdbBytes)
-	if err != nil {
-		return fmt.Errorf("failed to read RDB file: %w", err)
-	}
-
-	r := bytes.NewReader(rdbBytes)
-	rdbData, err := rdb.ParseRDB(r)
-
-	store := rdbData.MapToStore()
-
-	appState.SetStore(store)
-
-	fmt.Printf("Connected to master server at %s\n", masterAddr)
-
-	handShakeOk = true
-	go serveMaster(appState, conn, reader)
-
-	return nil
-}
@@ -0,0 +1,196 @@
+package aof
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type FsyncPolicy string
+
+const (
+	FsyncAlways   FsyncPolicy = "always"
+	FsyncEverySec FsyncPolicy = "everysec"
+	FsyncNo       FsyncPolicy = "no"
+)
+
+var (
+	ErrClosed            = errors.New("AOF is closed")
+	ErrRewriteInProgress = errors.New("Background append only file rewriting already in progress")
+)
+
+func ParseFsyncPolicy(s string) (FsyncPolicy, error) {
+	switch p := FsyncPolicy(s); p {
+	case FsyncAlways, FsyncEverySec, FsyncNo:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid appendfsync policy %q, must be one of always, everysec, no", s)
+	}
+}
+
+// AOF appends commands to the current incremental file of a Redis 7 style
+// multi part append only file.
+//
+// With FsyncAlways every write is synced before Write returns. FsyncEverySec
+// hands writes to the kernel and a background goroutine syncs them once per
+// second, so at most one second of writes can be lost. FsyncNo leaves
+// flushing entirely to the operating system.
+type AOF struct {
+	mu       sync.Mutex
+	dir      string
+	filename string
+	policy   FsyncPolicy
+	manifest *manifest
+	file     *os.File
+	dirty    bool
+	done     chan struct{}
+
+	// INFO: size is the total size of the base and incr files, baseSize is
+	// what it was right after the last rewrite (or on startup). Their ratio
+	// drives auto-aof-rewrite-percentage.
+	size      int64
+	baseSize  int64
+	rewriting bool
+}
+
+func Open(dir, filename string, policy FsyncPolicy) (*AOF, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create dir %s: %w", dir, err)
+	}
+
+	manifestPath := filepath.Join(dir, manifestName(filename))
+	m, err := loadManifest(manifestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		m = &manifest{}
+	} else if err != nil {
+		return nil, fmt.Errorf("load manifest: %w", err)
+	}
+
+	incr, ok := m.lastIncr()
+	if !ok {
+		incr = manifestEntry{name: incrName(filename, 1), seq: 1, fileTyp: incrFile}
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, incr.name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", incr.name, err)
+	}
+
+	if !ok {
+		m.incrs = append(m.incrs, incr)
+		if err := m.write(manifestPath); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("write manifest: %w", err)
+		}
+	}
+
+	size := m.diskSize(dir)
+
+	a := &AOF{
+		dir:      dir,
+		filename: filename,
+		policy:   policy,
+		manifest: m,
+		file:     f,
+		done:     make(chan struct{}),
+		size:     size,
+		baseSize: size,
+	}
+
+	if policy == FsyncEverySec {
+		go a.fsyncLoop()
+	}
+
+	return a, nil
+}
+
+func (a *AOF) Write(p []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return ErrClosed
+	}
+
+	n, err := a.file.Write(p)
+	a.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write AOF: %w", err)
+	}
+
+	if a.policy == FsyncAlways {
+		if err := a.file.Sync(); err != nil {
+			return fmt.Errorf("fsync AOF: %w", err)
+		}
+		return nil
+	}
+
+	a.dirty = true
+	return nil
+}
+
+// Sizes returns the current AOF size and its size after the last rewrite.
+func (a *AOF) Sizes() (current, base int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.size, a.baseSize
+}
+
+func (a *AOF) Rewriting() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.rewriting
+}
+
+func (a *AOF) manifestPath() string {
+	return filepath.Join(a.dir, manifestName(a.filename))
+}
+
+func (a *AOF) fsyncLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			if err := a.sync(); err != nil {
+				fmt.Printf("Background AOF fsync failed: %s\n", err.Error())
+			}
+		}
+	}
+}
+
+func (a *AOF) sync() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil || !a.dirty {
+		return nil
+	}
+	a.dirty = false
+	return a.file.Sync()
+}
+
+// Close syncs pending writes regardless of the fsync policy and closes the
+// current incremental file.
+func (a *AOF) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+
+	close(a.done)
+
+	syncErr := a.file.Sync()
+	closeErr := a.file.Close()
+	a.file = nil
+
+	return errors.Join(syncErr, closeErr)
+}
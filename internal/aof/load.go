@@ -0,0 +1,167 @@
+package aof
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+)
+
+const rdbPreamble = "REDIS"
+
+type LoadOptions struct {
+	// AllowTruncated accepts a last command cut short by a crash and
+	// truncates the file to the last complete command, like Redis'
+	// aof-load-truncated.
+	AllowTruncated bool
+	// LoadRDB is called with the base file when it is an RDB snapshot.
+	LoadRDB func(r io.Reader) error
+	// Exec runs a single replayed command.
+	Exec func(cmd command.Command) error
+}
+
+// Exists reports whether dir holds a manifest for filename.
+func Exists(dir, filename string) bool {
+	_, err := os.Stat(filepath.Join(dir, manifestName(filename)))
+	return err == nil
+}
+
+// Load rebuilds the dataset from the base file and the incremental files
+// listed in the manifest, in order.
+func Load(dir, filename string, opts LoadOptions) error {
+	m, err := loadManifest(filepath.Join(dir, manifestName(filename)))
+	if err != nil {
+		return fmt.Errorf("load manifest: %w", err)
+	}
+
+	if m.base != nil {
+		if err := loadFile(dir, *m.base, false, opts); err != nil {
+			return err
+		}
+	}
+
+	for i, incr := range m.incrs {
+		last := i == len(m.incrs)-1
+		if err := loadFile(dir, incr, last, opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func loadFile(dir string, entry manifestEntry, last bool, opts LoadOptions) error {
+	path := filepath.Join(dir, entry.name)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", entry.name, err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+
+	preamble, err := reader.Peek(len(rdbPreamble))
+	if err == nil && string(preamble) == rdbPreamble {
+		if entry.fileTyp != baseFile {
+			return fmt.Errorf("%s: RDB preamble is only allowed in the base file", entry.name)
+		}
+		if opts.LoadRDB == nil {
+			return fmt.Errorf("%s: RDB base file is not supported", entry.name)
+		}
+		if err := opts.LoadRDB(reader); err != nil {
+			return fmt.Errorf("load RDB base %s: %w", entry.name, err)
+		}
+		fmt.Printf("Loaded RDB base file %s\n", entry.name)
+		return nil
+	}
+
+	count, validEnd, err := replay(reader, opts.Exec)
+	if err == nil {
+		fmt.Printf("Loaded %d commands from %s\n", count, entry.name)
+		return nil
+	}
+
+	var truncErr *truncatedError
+	if !errors.As(err, &truncErr) {
+		return fmt.Errorf("%s: %w", entry.name, err)
+	}
+
+	if !last || !opts.AllowTruncated {
+		return fmt.Errorf("%s: %w", entry.name, err)
+	}
+
+	fmt.Printf("!!! Warning: short read while loading %s, truncating it to offset %d (%s)\n", entry.name, validEnd, truncErr.reason)
+	f.Close()
+	if err := os.Truncate(path, validEnd); err != nil {
+		return fmt.Errorf("truncate %s: %w", entry.name, err)
+	}
+	return nil
+}
+
+type truncatedError struct {
+	reason string
+}
+
+func (e *truncatedError) Error() string {
+	return "unexpected end of file: " + e.reason
+}
+
+// replay executes every command in reader. It returns the number of
+// commands run and the offset right after the last command that can be
+// kept, which excludes a MULTI block missing its EXEC.
+func replay(reader *bufio.Reader, exec func(cmd command.Command) error) (int, int64, error) {
+	var pos, validEnd, txnStart int64
+	inTxn := false
+	count := 0
+
+	for {
+		if _, err := reader.Peek(1); err == io.EOF {
+			break
+		}
+
+		val, n, err := resp.DecodeRESPInputExact(reader, resp.RESPArr)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			end := validEnd
+			if inTxn {
+				end = txnStart
+			}
+			return count, end, &truncatedError{reason: "incomplete command"}
+		}
+		if err != nil {
+			return count, validEnd, fmt.Errorf("bad file format at offset %d: %w", pos, err)
+		}
+
+		cmd, err := command.ParseCommandFromRESP(val)
+		if err != nil {
+			return count, validEnd, fmt.Errorf("bad command at offset %d: %w", pos, err)
+		}
+
+		switch cmd.Name {
+		case command.MULTI:
+			inTxn, txnStart = true, pos
+		case command.EXEC:
+			inTxn = false
+		}
+
+		if err := exec(cmd); err != nil {
+			fmt.Printf("Error replaying %s at offset %d: %s\n", cmd.Name, pos, err.Error())
+		}
+
+		count++
+		pos += int64(n)
+		if !inTxn {
+			validEnd = pos
+		}
+	}
+
+	if inTxn {
+		return count, txnStart, &truncatedError{reason: "MULTI without EXEC"}
+	}
+	return count, pos, nil
+}
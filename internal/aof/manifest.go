@@ -0,0 +1,188 @@
+package aof
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type fileType byte
+
+const (
+	baseFile    fileType = 'b'
+	historyFile fileType = 'h'
+	incrFile    fileType = 'i'
+)
+
+type manifestEntry struct {
+	name    string
+	seq     int
+	fileTyp fileType
+}
+
+// manifest mirrors the Redis 7 multi part AOF manifest: at most one base
+// file followed by the incremental files that must be replayed on top of it.
+// Each line has the form "file <name> seq <seq> type <b|h|i>".
+type manifest struct {
+	base    *manifestEntry
+	history []manifestEntry
+	incrs   []manifestEntry
+}
+
+func manifestName(filename string) string {
+	return filename + ".manifest"
+}
+
+func incrName(filename string, seq int) string {
+	return fmt.Sprintf("%s.%d.incr.aof", filename, seq)
+}
+
+func baseName(filename string, seq int) string {
+	return fmt.Sprintf("%s.%d.base.rdb", filename, seq)
+}
+
+func loadManifest(path string) (*manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := &manifest{}
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry, err := parseManifestLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest line %d: %w", lineNo, err)
+		}
+
+		switch entry.fileTyp {
+		case baseFile:
+			if m.base != nil {
+				return nil, fmt.Errorf("invalid manifest line %d: more than one base file", lineNo)
+			}
+			m.base = &entry
+		case historyFile:
+			m.history = append(m.history, entry)
+		case incrFile:
+			if n := len(m.incrs); n > 0 && entry.seq <= m.incrs[n-1].seq {
+				return nil, fmt.Errorf("invalid manifest line %d: incr file sequence out of order", lineNo)
+			}
+			m.incrs = append(m.incrs, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func parseManifestLine(line string) (manifestEntry, error) {
+	fields := strings.Fields(line)
+	if len(fields)%2 != 0 {
+		return manifestEntry{}, errors.New("expected key value pairs")
+	}
+
+	var entry manifestEntry
+	for i := 0; i < len(fields); i += 2 {
+		key, val := fields[i], fields[i+1]
+		switch key {
+		case "file":
+			entry.name = val
+		case "seq":
+			seq, err := strconv.Atoi(val)
+			if err != nil || seq < 0 {
+				return manifestEntry{}, fmt.Errorf("invalid seq %q", val)
+			}
+			entry.seq = seq
+		case "type":
+			if len(val) != 1 {
+				return manifestEntry{}, fmt.Errorf("invalid type %q", val)
+			}
+			entry.fileTyp = fileType(val[0])
+		default:
+			// INFO: unknown keys are ignored for forward compatibility, as Redis does
+		}
+	}
+
+	if entry.name == "" || filepath.Base(entry.name) != entry.name {
+		return manifestEntry{}, fmt.Errorf("invalid file name %q", entry.name)
+	}
+
+	switch entry.fileTyp {
+	case baseFile, historyFile, incrFile:
+	default:
+		return manifestEntry{}, fmt.Errorf("invalid type %q", string(entry.fileTyp))
+	}
+
+	return entry, nil
+}
+
+// write atomically replaces the manifest at path.
+func (m *manifest) write(path string) error {
+	var sb strings.Builder
+	writeEntry := func(e manifestEntry) {
+		fmt.Fprintf(&sb, "file %s seq %d type %c\n", e.name, e.seq, e.fileTyp)
+	}
+
+	if m.base != nil {
+		writeEntry(*m.base)
+	}
+	for _, e := range m.history {
+		writeEntry(e)
+	}
+	for _, e := range m.incrs {
+		writeEntry(e)
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(sb.String()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (m *manifest) lastIncr() (manifestEntry, bool) {
+	if len(m.incrs) == 0 {
+		return manifestEntry{}, false
+	}
+	return m.incrs[len(m.incrs)-1], true
+}
+
+// diskSize sums the sizes of the base and incr files found in dir.
+func (m *manifest) diskSize(dir string) int64 {
+	files := m.incrs
+	if m.base != nil {
+		files = append([]manifestEntry{*m.base}, files...)
+	}
+
+	var size int64
+	for _, e := range files {
+		if info, err := os.Stat(filepath.Join(dir, e.name)); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
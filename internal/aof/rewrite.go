@@ -0,0 +1,146 @@
+package aof
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Rewrite is an AOF rewrite in progress. The incr files up to lastIncr are
+// replaced by a new base file once Commit succeeds; writes made after the
+// rewrite started go to a fresh incr file and are kept.
+type Rewrite struct {
+	a        *AOF
+	lastIncr int
+}
+
+// StartRewrite rotates to a new incr file so that the dataset snapshot taken
+// by the caller lines up with the point where the new file begins.
+func (a *AOF) StartRewrite() (*Rewrite, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return nil, ErrClosed
+	}
+	if a.rewriting {
+		return nil, ErrRewriteInProgress
+	}
+
+	cur, _ := a.manifest.lastIncr()
+	next := manifestEntry{name: incrName(a.filename, cur.seq+1), seq: cur.seq + 1, fileTyp: incrFile}
+
+	f, err := os.OpenFile(filepath.Join(a.dir, next.name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", next.name, err)
+	}
+
+	a.manifest.incrs = append(a.manifest.incrs, next)
+	if err := a.manifest.write(a.manifestPath()); err != nil {
+		a.manifest.incrs = a.manifest.incrs[:len(a.manifest.incrs)-1]
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("write manifest: %w", err)
+	}
+
+	if err := errors.Join(a.file.Sync(), a.file.Close()); err != nil {
+		fmt.Printf("Failed to close AOF file %s: %s\n", cur.name, err.Error())
+	}
+	a.file = f
+	a.dirty = false
+	a.rewriting = true
+
+	return &Rewrite{a: a, lastIncr: cur.seq}, nil
+}
+
+// Commit writes the new base file with writeBase and atomically switches the
+// manifest over to it, then removes the files it made obsolete. On failure
+// the previous base and incr files are left untouched.
+func (r *Rewrite) Commit(writeBase func(w io.Writer) error) error {
+	a := r.a
+	defer func() {
+		a.mu.Lock()
+		a.rewriting = false
+		a.mu.Unlock()
+	}()
+
+	a.mu.Lock()
+	seq := 1
+	if a.manifest.base != nil {
+		seq = a.manifest.base.seq + 1
+	}
+	a.mu.Unlock()
+
+	base := manifestEntry{name: baseName(a.filename, seq), seq: seq, fileTyp: baseFile}
+	if err := writeBaseFile(a.dir, base.name, writeBase); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	obsolete := make([]manifestEntry, 0)
+	if a.manifest.base != nil {
+		obsolete = append(obsolete, *a.manifest.base)
+	}
+	obsolete = append(obsolete, a.manifest.history...)
+
+	m := &manifest{base: &base}
+	for _, e := range a.manifest.incrs {
+		if e.seq <= r.lastIncr {
+			obsolete = append(obsolete, e)
+			continue
+		}
+		m.incrs = append(m.incrs, e)
+	}
+
+	if err := m.write(a.manifestPath()); err != nil {
+		os.Remove(filepath.Join(a.dir, base.name))
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	a.manifest = m
+
+	for _, e := range obsolete {
+		if err := os.Remove(filepath.Join(a.dir, e.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Failed to remove obsolete AOF file %s: %s\n", e.name, err.Error())
+		}
+	}
+
+	a.size = m.diskSize(a.dir)
+	a.baseSize = a.size
+
+	return nil
+}
+
+func writeBaseFile(dir, name string, writeBase func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(dir, "temp-rewriteaof-*.aof")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+
+	ok := false
+	defer func() {
+		if !ok {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := writeBase(tmp); err != nil {
+		return fmt.Errorf("write base file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return fmt.Errorf("rename %s: %w", tmp.Name(), err)
+	}
+
+	ok = true
+	return nil
+}
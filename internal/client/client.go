@@ -2,7 +2,9 @@ package client
 
 import (
 	"context"
+	"strconv"
 
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
 	"github.com/0x222fe/codecrafters-redis-go/internal/connection"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
@@ -14,6 +16,17 @@ type Client struct {
 	Transaction *Transaction
 	Propagated  bool
 	SubMode     bool
+	// Loading marks the client replaying the AOF on startup, whose writes
+	// must not be propagated again.
+	Loading bool
+	// ReplCapaEOF is set when a replica announced it can read an RDB
+	// delimited by an EOF mark, which lets the master stream it.
+	ReplCapaEOF bool
+	// DB is the index of the database selected with SELECT.
+	DB int
+
+	rewrite   []command.Command
+	rewritten bool
 }
 
 func NewClient(ctx context.Context, conn *connection.Connection) *Client {
@@ -29,34 +42,72 @@ func (c *Client) StartTransaction() {
 	c.Transaction = NewTransaction()
 }
 
-func (c *Client) ExecTransaction(s *state.AppState) ([]resp.RESPValue, error) {
+// ExecTransaction runs the queued commands and returns their responses along
+// with the write commands that succeeded, which are to be propagated in the
+// database selected when EXEC was called.
+func (c *Client) ExecTransaction(s *state.AppState) ([]resp.RESPValue, []command.Command, error) {
 	defer func() {
 		c.Transaction = nil
-		s.GetStore().Unwatch(c.Conn.ID)
+		s.Unwatch(c.Conn.ID)
 	}()
 
 	c.Transaction.Executing = true
 
 	if len(c.Transaction.Commands) == 0 {
-		return []resp.RESPValue{}, nil
+		return []resp.RESPValue{}, nil, nil
 	}
 
-	if !s.GetStore().WatchesValid(c.Conn.ID) {
-		return nil, nil
+	if !s.WatchesValid(c.Conn.ID) {
+		return nil, nil, nil
 	}
 
+	writes := make([]command.Command, 0)
+	db := c.DB
 	for _, cmd := range c.Transaction.Commands {
 		err := cmd.Handler.Handle(c, s, cmd.Command)
+		propagated := c.PropagatedCommands(cmd.Command)
 		if err != nil {
 			c.Transaction.WriteResp(resp.NewError(err))
+			continue
+		}
+		if !cmd.IsWrite || len(propagated) == 0 {
+			continue
 		}
+
+		// INFO: a SELECT queued in the transaction moves the following
+		// writes to another database, see AppState.Propagate
+		if c.DB != db {
+			db = c.DB
+			writes = append(writes, command.Command{Name: command.SELECT, Args: []string{strconv.Itoa(db)}})
+		}
+		writes = append(writes, propagated...)
 	}
 	res := c.Transaction.Responses
-	return res, nil
+	return res, writes, nil
+}
+
+// PropagateAs replaces the command being executed in the replication stream
+// and the AOF, e.g. to turn a blocking pop into a plain one. Calling it with
+// no commands suppresses propagation entirely.
+func (c *Client) PropagateAs(cmds ...command.Command) {
+	c.rewrite = cmds
+	c.rewritten = true
+}
+
+// PropagatedCommands returns what should be propagated for cmd and clears
+// any rewrite registered by the handler.
+func (c *Client) PropagatedCommands(cmd command.Command) []command.Command {
+	if !c.rewritten {
+		return []command.Command{cmd}
+	}
+
+	cmds := c.rewrite
+	c.rewrite, c.rewritten = nil, false
+	return cmds
 }
 
 func (c *Client) DiscardTransaction(s *state.AppState) {
-	s.GetStore().Unwatch(c.Conn.ID)
+	s.Unwatch(c.Conn.ID)
 	c.Transaction = nil
 }
 
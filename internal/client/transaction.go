@@ -13,6 +13,7 @@ type TransactionCommandHandler interface {
 type TxnCommand struct {
 	Command command.Command
 	Handler TransactionCommandHandler
+	IsWrite bool
 }
 
 type Transaction struct {
@@ -22,48 +22,109 @@ const (
 )
 
 const (
-	PING        CommandKey = "PING"
-	ECHO        CommandKey = "ECHO"
-	SET         CommandKey = "SET"
-	GET         CommandKey = "GET"
-	CONFIG      CommandKey = "CONFIG"
-	KEYS        CommandKey = "KEYS"
-	INFO        CommandKey = "INFO"
-	REPLCONF    CommandKey = "REPLCONF"
-	PSYNC       CommandKey = "PSYNC"
-	WAIT        CommandKey = "WAIT"
-	TYPE        CommandKey = "TYPE"
-	XADD        CommandKey = "XADD"
-	XRANGE      CommandKey = "XRANGE"
-	XREAD       CommandKey = "XREAD"
-	INCR        CommandKey = "INCR"
-	MULTI       CommandKey = "MULTI"
-	EXEC        CommandKey = "EXEC"
-	DISCARD     CommandKey = "DISCARD"
-	LPUSH       CommandKey = "LPUSH"
-	RPUSH       CommandKey = "RPUSH"
-	LRANGE      CommandKey = "LRANGE"
-	LLEN        CommandKey = "LLEN"
-	LPOP        CommandKey = "LPOP"
-	BLPOP       CommandKey = "BLPOP"
-	RPOP        CommandKey = "RPOP"
-	SUBSCRIBE   CommandKey = "SUBSCRIBE"
-	UNSUBSCRIBE CommandKey = "UNSUBSCRIBE"
-	PUBLISH     CommandKey = "PUBLISH"
-	ZADD        CommandKey = "ZADD"
-	ZRANK       CommandKey = "ZRANK"
-	ZRANGE      CommandKey = "ZRANGE"
-	ZCARD       CommandKey = "ZCARD"
-	ZSCORE      CommandKey = "ZSCORE"
-	ZREM        CommandKey = "ZREM"
-	GEOADD      CommandKey = "GEOADD"
-	GEOPOS      CommandKey = "GEOPOS"
-	GEODIST     CommandKey = "GEODIST"
-	GEOSEARCH   CommandKey = "GEOSEARCH"
-	ACL         CommandKey = "ACL"
-	AUTH        CommandKey = "AUTH"
-	WATCH       CommandKey = "WATCH"
-	UNWATCH     CommandKey = "UNWATCH"
+	PING         CommandKey = "PING"
+	ECHO         CommandKey = "ECHO"
+	SET          CommandKey = "SET"
+	GET          CommandKey = "GET"
+	CONFIG       CommandKey = "CONFIG"
+	KEYS         CommandKey = "KEYS"
+	INFO         CommandKey = "INFO"
+	REPLCONF     CommandKey = "REPLCONF"
+	PSYNC        CommandKey = "PSYNC"
+	WAIT         CommandKey = "WAIT"
+	TYPE         CommandKey = "TYPE"
+	XADD         CommandKey = "XADD"
+	XRANGE       CommandKey = "XRANGE"
+	XREAD        CommandKey = "XREAD"
+	INCR         CommandKey = "INCR"
+	MULTI        CommandKey = "MULTI"
+	EXEC         CommandKey = "EXEC"
+	DISCARD      CommandKey = "DISCARD"
+	LPUSH        CommandKey = "LPUSH"
+	RPUSH        CommandKey = "RPUSH"
+	LRANGE       CommandKey = "LRANGE"
+	LLEN         CommandKey = "LLEN"
+	LPOP         CommandKey = "LPOP"
+	BLPOP        CommandKey = "BLPOP"
+	RPOP         CommandKey = "RPOP"
+	SUBSCRIBE    CommandKey = "SUBSCRIBE"
+	UNSUBSCRIBE  CommandKey = "UNSUBSCRIBE"
+	PSUBSCRIBE   CommandKey = "PSUBSCRIBE"
+	PUNSUBSCRIBE CommandKey = "PUNSUBSCRIBE"
+	PUBLISH      CommandKey = "PUBLISH"
+	PUBSUB       CommandKey = "PUBSUB"
+	SSUBSCRIBE   CommandKey = "SSUBSCRIBE"
+	SUNSUBSCRIBE CommandKey = "SUNSUBSCRIBE"
+	SPUBLISH     CommandKey = "SPUBLISH"
+	ZADD         CommandKey = "ZADD"
+	ZRANK        CommandKey = "ZRANK"
+	ZRANGE       CommandKey = "ZRANGE"
+	ZCARD        CommandKey = "ZCARD"
+	ZSCORE       CommandKey = "ZSCORE"
+	ZREM         CommandKey = "ZREM"
+	GEOADD       CommandKey = "GEOADD"
+	GEOPOS       CommandKey = "GEOPOS"
+	GEODIST      CommandKey = "GEODIST"
+	GEOSEARCH    CommandKey = "GEOSEARCH"
+	ACL          CommandKey = "ACL"
+	AUTH         CommandKey = "AUTH"
+	WATCH        CommandKey = "WATCH"
+	UNWATCH      CommandKey = "UNWATCH"
+	SAVE         CommandKey = "SAVE"
+	BGSAVE       CommandKey = "BGSAVE"
+	LASTSAVE     CommandKey = "LASTSAVE"
+	BGREWRITEAOF CommandKey = "BGREWRITEAOF"
+	REPLICAOF    CommandKey = "REPLICAOF"
+	SLAVEOF      CommandKey = "SLAVEOF"
+	DEL          CommandKey = "DEL"
+	UNLINK       CommandKey = "UNLINK"
+	EXISTS       CommandKey = "EXISTS"
+	TOUCH        CommandKey = "TOUCH"
+	EXPIRE       CommandKey = "EXPIRE"
+	PEXPIRE      CommandKey = "PEXPIRE"
+	EXPIREAT     CommandKey = "EXPIREAT"
+	PEXPIREAT    CommandKey = "PEXPIREAT"
+	TTL          CommandKey = "TTL"
+	PTTL         CommandKey = "PTTL"
+	EXPIRETIME   CommandKey = "EXPIRETIME"
+	PEXPIRETIME  CommandKey = "PEXPIRETIME"
+	PERSIST      CommandKey = "PERSIST"
+	SCAN         CommandKey = "SCAN"
+	HSCAN        CommandKey = "HSCAN"
+	SSCAN        CommandKey = "SSCAN"
+	ZSCAN        CommandKey = "ZSCAN"
+	SELECT       CommandKey = "SELECT"
+	MOVE         CommandKey = "MOVE"
+	SWAPDB       CommandKey = "SWAPDB"
+	DBSIZE       CommandKey = "DBSIZE"
+	FLUSHDB      CommandKey = "FLUSHDB"
+	FLUSHALL     CommandKey = "FLUSHALL"
+	DECR         CommandKey = "DECR"
+	INCRBY       CommandKey = "INCRBY"
+	DECRBY       CommandKey = "DECRBY"
+	INCRBYFLOAT  CommandKey = "INCRBYFLOAT"
+	MGET         CommandKey = "MGET"
+	MSET         CommandKey = "MSET"
+	MSETNX       CommandKey = "MSETNX"
+	APPEND       CommandKey = "APPEND"
+	STRLEN       CommandKey = "STRLEN"
+	GETRANGE     CommandKey = "GETRANGE"
+	SETRANGE     CommandKey = "SETRANGE"
+	GETSET       CommandKey = "GETSET"
+	GETDEL       CommandKey = "GETDEL"
+	GETEX        CommandKey = "GETEX"
+	SETNX        CommandKey = "SETNX"
+	SETEX        CommandKey = "SETEX"
+	PSETEX       CommandKey = "PSETEX"
+	LCS          CommandKey = "LCS"
+	OBJECT       CommandKey = "OBJECT"
+	SETBIT       CommandKey = "SETBIT"
+	GETBIT       CommandKey = "GETBIT"
+	BITCOUNT     CommandKey = "BITCOUNT"
+	BITPOS       CommandKey = "BITPOS"
+	BITOP        CommandKey = "BITOP"
+	BITFIELD     CommandKey = "BITFIELD"
+	BITFIELD_RO  CommandKey = "BITFIELD_RO"
 )
 
 func ParseCommandFromRESP(v resp.RESPValue) (Command, error) {
@@ -5,22 +5,57 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 )
 
+var maxMemoryPolicies = []string{
+	"noeviction",
+	"allkeys-lru",
+	"volatile-lru",
+	"allkeys-lfu",
+	"volatile-lfu",
+	"allkeys-random",
+	"volatile-random",
+	"volatile-ttl",
+}
+
+// DefaultDbfilename is the file SAVE, BGSAVE and disk based replication
+// write to when -dbfilename is not set. Startup only loads an RDB file when
+// -dbfilename is given.
+const DefaultDbfilename = "dump.rdb"
+
 type Config struct {
-	Dir        string
-	Dbfilename string
-	Port       int
+	Dir            string
+	Dbfilename     string
+	RDBCompression bool
+	Port           int
+	Hz             int
+	Databases      int
+
+	MaxMemory        int64
+	MaxMemoryPolicy  string
+	MaxMemorySamples int
+
+	NotifyKeyspaceEvents KeyspaceEvents
+
+	ClientOutputBufferLimits OutputBufferLimits
+
+	AppendOnly       bool
+	AppendDirname    string
+	AppendFilename   string
+	AppendFsync      string
+	AOFLoadTruncated bool
 
-	AppendOnly     bool
-	AppendDirname  string
-	AppendFilename string
-	AppendFsync    string
+	AutoAOFRewritePercentage int
+	AutoAOFRewriteMinSize    int64
 
-	MasterHost string
-	MasterPort int
+	MasterHost       string
+	MasterPort       int
+	ReplDisklessSync bool
+	ReplBacklogSize  int64
 }
 
 func ParseFlags() (*Config, error) {
@@ -28,19 +63,49 @@ func ParseFlags() (*Config, error) {
 
 	flag.StringVar(&cfg.Dir, "dir", "", "Directory to store Redis data")
 	flag.StringVar(&cfg.Dbfilename, "dbfilename", "", "Name of the Redis database file")
+	var rdbCompression string
+	flag.StringVar(&rdbCompression, "rdbcompression", "yes", "Compress string objects using LZF when dumping .rdb databases")
 	flag.IntVar(&cfg.Port, "port", 6379, "Port to bind the Redis server to")
+	flag.IntVar(&cfg.Hz, "hz", 10, "How many times per second background tasks such as active expiry run")
+	flag.IntVar(&cfg.Databases, "databases", 16, "Number of databases, selected with SELECT <dbid>")
+	var maxMemory string
+	flag.StringVar(&maxMemory, "maxmemory", "0", "Memory limit for the dataset, 0 for no limit")
+	flag.StringVar(&cfg.MaxMemoryPolicy, "maxmemory-policy", "noeviction", "How keys are evicted once maxmemory is reached")
+	flag.IntVar(&cfg.MaxMemorySamples, "maxmemory-samples", 5, "Number of keys sampled for each eviction")
+	var notifyKeyspaceEvents string
+	flag.StringVar(&notifyKeyspaceEvents, "notify-keyspace-events", "", "Keyspace event classes published over Pub/Sub, e.g. \"KEA\"")
+	var clientOutputBufferLimit string
+	flag.StringVar(&clientOutputBufferLimit, "client-output-buffer-limit", "", "Output buffer limits per client class, e.g. \"pubsub 32mb 8mb 60\"")
 
 	var appendOnly string
 	flag.StringVar(&appendOnly, "appendonly", "no", "Controls whether AOF persistence is enabled or disabled")
 	flag.StringVar(&cfg.AppendDirname, "appenddirname", "appendonlydir", "The subdirectory under dir where AOF and manifest files are stored")
 	flag.StringVar(&cfg.AppendFilename, "appendfilename", "appendonly.aof", "The name of the append-only file that records write operations")
 	flag.StringVar(&cfg.AppendFsync, "appendfsync", "everysec", "How often buffered writes are flushed to the AOF file on disk")
+	flag.IntVar(&cfg.AutoAOFRewritePercentage, "auto-aof-rewrite-percentage", 100, "Rewrite the AOF once it grows by this percentage over its size after the last rewrite, 0 disables automatic rewrites")
+	var autoAOFRewriteMinSize string
+	flag.StringVar(&autoAOFRewriteMinSize, "auto-aof-rewrite-min-size", "64mb", "Minimum AOF size before an automatic rewrite is considered")
+	var aofLoadTruncated string
+	flag.StringVar(&aofLoadTruncated, "aof-load-truncated", "yes", "Load an AOF whose last command was truncated instead of refusing to start")
 
+	var replDisklessSync string
+	flag.StringVar(&replDisklessSync, "repl-diskless-sync", "yes", "Stream the RDB to replicas during full resynchronization instead of saving it to disk first")
+	var replBacklogSize string
+	flag.StringVar(&replBacklogSize, "repl-backlog-size", "1mb", "Size of the replication backlog kept for partial resynchronization")
 	replicaof := new(string)
 	flag.StringVar(replicaof, "replicaof", "", "Master server to replicate from (format: <host> <port>)")
 
 	flag.Parse()
 
+	switch rdbCompression {
+	case "yes":
+		cfg.RDBCompression = true
+	case "no":
+		cfg.RDBCompression = false
+	default:
+		return nil, errors.New("rdbcompression must be 'yes' or 'no'")
+	}
+
 	switch appendOnly {
 	case "yes":
 		cfg.AppendOnly = true
@@ -50,6 +115,71 @@ func ParseFlags() (*Config, error) {
 		return nil, errors.New("appendonly must be 'yes' or 'no'")
 	}
 
+	switch aofLoadTruncated {
+	case "yes":
+		cfg.AOFLoadTruncated = true
+	case "no":
+		cfg.AOFLoadTruncated = false
+	default:
+		return nil, errors.New("aof-load-truncated must be 'yes' or 'no'")
+	}
+
+	switch replDisklessSync {
+	case "yes":
+		cfg.ReplDisklessSync = true
+	case "no":
+		cfg.ReplDisklessSync = false
+	default:
+		return nil, errors.New("repl-diskless-sync must be 'yes' or 'no'")
+	}
+
+	if cfg.Hz < 1 || cfg.Hz > 500 {
+		return nil, errors.New("hz must be between 1 and 500")
+	}
+
+	if cfg.Databases < 1 {
+		return nil, errors.New("databases must be at least 1")
+	}
+
+	if cfg.AutoAOFRewritePercentage < 0 {
+		return nil, errors.New("auto-aof-rewrite-percentage must not be negative")
+	}
+
+	minSize, err := ParseMemory(autoAOFRewriteMinSize)
+	if err != nil {
+		return nil, fmt.Errorf("auto-aof-rewrite-min-size: %w", err)
+	}
+	cfg.AutoAOFRewriteMinSize = minSize
+
+	cfg.MaxMemory, err = ParseMemory(maxMemory)
+	if err != nil {
+		return nil, fmt.Errorf("maxmemory: %w", err)
+	}
+
+	if !slices.Contains(maxMemoryPolicies, cfg.MaxMemoryPolicy) {
+		return nil, fmt.Errorf("maxmemory-policy must be one of %s", strings.Join(maxMemoryPolicies, ", "))
+	}
+
+	if cfg.MaxMemorySamples < 1 || cfg.MaxMemorySamples > 64 {
+		return nil, errors.New("maxmemory-samples must be between 1 and 64")
+	}
+
+	cfg.NotifyKeyspaceEvents, err = ParseKeyspaceEvents(notifyKeyspaceEvents)
+	if err != nil {
+		return nil, fmt.Errorf("notify-keyspace-events: %w", err)
+	}
+
+	cfg.ClientOutputBufferLimits, err = ParseOutputBufferLimits(clientOutputBufferLimit)
+	if err != nil {
+		return nil, fmt.Errorf("client-output-buffer-limit: %w", err)
+	}
+
+	backlogSize, err := ParseMemory(replBacklogSize)
+	if err != nil {
+		return nil, fmt.Errorf("repl-backlog-size: %w", err)
+	}
+	cfg.ReplBacklogSize = backlogSize
+
 	if cfg.Dir == "" {
 		dir, err := os.Getwd()
 		if err != nil {
@@ -73,3 +203,41 @@ func ParseFlags() (*Config, error) {
 
 	return cfg, nil
 }
+
+// RDBPath returns the path of the RDB file to write.
+func (cfg Config) RDBPath() string {
+	name := cfg.Dbfilename
+	if name == "" {
+		name = DefaultDbfilename
+	}
+	return filepath.Join(cfg.Dir, name)
+}
+
+// ParseMemory parses a memory amount such as "64mb", "1gb" or "100". Units
+// are powers of 1024 and case insensitive, as in redis.conf.
+func ParseMemory(s string) (int64, error) {
+	lower := strings.ToLower(strings.TrimSpace(s))
+
+	units := []struct {
+		suffix string
+		mul    int64
+	}{
+		{"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+		{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10}, {"b", 1},
+	}
+
+	mul := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(lower, u.suffix) {
+			lower = strings.TrimSuffix(lower, u.suffix)
+			mul = u.mul
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(lower, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid memory amount %q", s)
+	}
+	return n * mul, nil
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KeyspaceEvents is the set of event classes enabled by
+// notify-keyspace-events.
+type KeyspaceEvents int
+
+const (
+	NotifyKeyspace KeyspaceEvents = 1 << iota
+	NotifyKeyevent
+	NotifyGeneric
+	NotifyString
+	NotifyList
+	NotifySet
+	NotifyHash
+	NotifyZSet
+	NotifyExpired
+	NotifyEvicted
+	NotifyStream
+	NotifyKeyMiss
+
+	// INFO: like in Redis, 'A' does not include key miss events
+	NotifyAll = NotifyGeneric | NotifyString | NotifyList | NotifySet | NotifyHash |
+		NotifyZSet | NotifyExpired | NotifyEvicted | NotifyStream
+)
+
+var keyspaceEventFlags = []struct {
+	flag  byte
+	class KeyspaceEvents
+}{
+	{'g', NotifyGeneric},
+	{'$', NotifyString},
+	{'l', NotifyList},
+	{'s', NotifySet},
+	{'h', NotifyHash},
+	{'z', NotifyZSet},
+	{'x', NotifyExpired},
+	{'e', NotifyEvicted},
+	{'t', NotifyStream},
+	{'K', NotifyKeyspace},
+	{'E', NotifyKeyevent},
+	{'m', NotifyKeyMiss},
+}
+
+// ParseKeyspaceEvents parses a notify-keyspace-events value such as "KEA" or
+// "Ex".
+func ParseKeyspaceEvents(s string) (KeyspaceEvents, error) {
+	var events KeyspaceEvents
+
+outer:
+	for i := 0; i < len(s); i++ {
+		if s[i] == 'A' {
+			events |= NotifyAll
+			continue
+		}
+		for _, f := range keyspaceEventFlags {
+			if f.flag == s[i] {
+				events |= f.class
+				continue outer
+			}
+		}
+		return 0, fmt.Errorf("invalid event class character '%c'", s[i])
+	}
+
+	return events, nil
+}
+
+func (events KeyspaceEvents) String() string {
+	var sb strings.Builder
+	for _, f := range keyspaceEventFlags {
+		if events&NotifyAll == NotifyAll && f.class&NotifyAll != 0 {
+			if f.class == NotifyGeneric {
+				sb.WriteByte('A')
+			}
+			continue
+		}
+		if events&f.class != 0 {
+			sb.WriteByte(f.flag)
+		}
+	}
+	return sb.String()
+}
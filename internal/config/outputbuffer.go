@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OutputBufferLimit is one class of client-output-buffer-limit. A client is
+// disconnected once its pending output exceeds Hard bytes, or stays above
+// Soft bytes for longer than SoftSeconds. Zero disables a limit.
+type OutputBufferLimit struct {
+	Hard        int64
+	Soft        int64
+	SoftSeconds int
+}
+
+// OutputBufferLimits holds the limits for each client class. Only the pubsub
+// class is enforced, normal and replica are accepted for compatibility with
+// redis.conf.
+type OutputBufferLimits struct {
+	Normal  OutputBufferLimit
+	Replica OutputBufferLimit
+	PubSub  OutputBufferLimit
+}
+
+// DefaultOutputBufferLimits are the Redis defaults.
+var DefaultOutputBufferLimits = OutputBufferLimits{
+	Normal:  OutputBufferLimit{},
+	Replica: OutputBufferLimit{Hard: 256 << 20, Soft: 64 << 20, SoftSeconds: 60},
+	PubSub:  OutputBufferLimit{Hard: 32 << 20, Soft: 8 << 20, SoftSeconds: 60},
+}
+
+// ParseOutputBufferLimits parses a list of "<class> <hard> <soft> <seconds>"
+// groups on top of the defaults, e.g. "pubsub 32mb 8mb 60".
+func ParseOutputBufferLimits(s string) (OutputBufferLimits, error) {
+	limits := DefaultOutputBufferLimits
+
+	fields := strings.Fields(s)
+	if len(fields)%4 != 0 {
+		return limits, fmt.Errorf("expected groups of <class> <hard> <soft> <seconds>, got %q", s)
+	}
+
+	for i := 0; i < len(fields); i += 4 {
+		var limit *OutputBufferLimit
+		switch strings.ToLower(fields[i]) {
+		case "normal":
+			limit = &limits.Normal
+		case "replica", "slave":
+			limit = &limits.Replica
+		case "pubsub":
+			limit = &limits.PubSub
+		default:
+			return limits, fmt.Errorf("invalid client class %q", fields[i])
+		}
+
+		hard, err := ParseMemory(fields[i+1])
+		if err != nil {
+			return limits, err
+		}
+		soft, err := ParseMemory(fields[i+2])
+		if err != nil {
+			return limits, err
+		}
+		seconds, err := strconv.Atoi(fields[i+3])
+		if err != nil || seconds < 0 {
+			return limits, fmt.Errorf("invalid soft limit seconds %q", fields[i+3])
+		}
+
+		*limit = OutputBufferLimit{Hard: hard, Soft: soft, SoftSeconds: seconds}
+	}
+
+	return limits, nil
+}
+
+// String renders the limits the way CONFIG GET reports them.
+func (l OutputBufferLimits) String() string {
+	format := func(class string, limit OutputBufferLimit) string {
+		return fmt.Sprintf("%s %d %d %d", class, limit.Hard, limit.Soft, limit.SoftSeconds)
+	}
+
+	return strings.Join([]string{
+		format("normal", l.Normal),
+		format("slave", l.Replica),
+		format("pubsub", l.PubSub),
+	}, " ")
+}
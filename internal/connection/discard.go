@@ -0,0 +1,29 @@
+package connection
+
+import (
+	"net"
+	"time"
+)
+
+// discardConn is a net.Conn that drops everything written to it, used by
+// internal clients such as the AOF loader that have nobody to reply to.
+type discardConn struct{}
+
+func (discardConn) Read(p []byte) (int, error)         { return 0, net.ErrClosed }
+func (discardConn) Write(p []byte) (int, error)        { return len(p), nil }
+func (discardConn) Close() error                       { return nil }
+func (discardConn) LocalAddr() net.Addr                { return internalAddr{} }
+func (discardConn) RemoteAddr() net.Addr               { return internalAddr{} }
+func (discardConn) SetDeadline(t time.Time) error      { return nil }
+func (discardConn) SetReadDeadline(t time.Time) error  { return nil }
+func (discardConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type internalAddr struct{}
+
+func (internalAddr) Network() string { return "internal" }
+func (internalAddr) String() string  { return "internal" }
+
+// NewDiscardConnection returns a connection whose replies are thrown away.
+func NewDiscardConnection() *Connection {
+	return NewConnection(discardConn{}, nil)
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func appendHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 2 {
+		return errors.New("wrong number of arguments for 'append' command")
+	}
+
+	key := args[0]
+	val, err := s.GetDB(c.DB).UpdateString(key, func(old []byte, exists bool) ([]byte, bool, error) {
+		val := make([]byte, 0, len(old)+len(args[1]))
+		return append(append(val, old...), args[1]...), true, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.NotifyKeyspaceEvent(config.NotifyString, "append", key, c.DB)
+	return writeResponse(c, resp.NewInt(int64(len(val))))
+}
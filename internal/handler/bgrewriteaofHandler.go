@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func bgrewriteaofHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 0 {
+		return errors.New("wrong number of arguments for 'bgrewriteaof' command")
+	}
+
+	if err := s.RewriteAOF(); err != nil {
+		return err
+	}
+
+	return writeResponse(c, resp.NewString("Background append only file rewriting started"))
+}
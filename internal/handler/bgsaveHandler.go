@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func bgsaveHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) > 1 {
+		return errors.New("wrong number of arguments for 'bgsave' command")
+	}
+
+	if len(args) == 1 && strings.ToUpper(args[0]) != "SCHEDULE" {
+		return errors.New("syntax error")
+	}
+
+	if !s.StartSave() {
+		return saveInProgressErr
+	}
+
+	// INFO: the snapshot is taken synchronously so the dump reflects the
+	// keyspace at the time BGSAVE was issued; encoding and disk I/O happen
+	// in the background.
+	dbs := s.Snapshot()
+
+	go func() {
+		err := saveRDB(s, dbs)
+		if err != nil {
+			fmt.Printf("Background saving error: %s\n", err.Error())
+		}
+		s.FinishSave(err == nil)
+	}()
+
+	return writeResponse(c, resp.NewString("Background saving started"))
+}
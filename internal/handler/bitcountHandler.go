@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/utils/bitutil"
+)
+
+// bitcountHandler implements BITCOUNT key [start end [BYTE|BIT]].
+func bitcountHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 1 {
+		return errors.New("wrong number of arguments for 'bitcount' command")
+	}
+	if len(args) != 1 && len(args) != 3 && len(args) != 4 {
+		return errors.New("syntax error")
+	}
+
+	start, end := int64(0), int64(-1)
+	bitUnit := false
+	if len(args) > 1 {
+		var err error
+		if start, end, err = parseBitRange(args[1], args[2]); err != nil {
+			return err
+		}
+		if len(args) == 4 {
+			if bitUnit, err = parseBitUnit(args[3]); err != nil {
+				return err
+			}
+		}
+	}
+
+	val, _, err := s.GetDB(c.DB).GetString(args[0])
+	if err != nil {
+		return err
+	}
+
+	first, last, ok := resolveBitRange(int64(len(val)), start, end, bitUnit)
+	if !ok {
+		return writeResponse(c, resp.NewInt(0))
+	}
+	return writeResponse(c, resp.NewInt(bitutil.Count(val, first, last)))
+}
+
+func parseBitRange(startArg, endArg string) (int64, int64, error) {
+	start, err := strconv.ParseInt(startArg, 10, 64)
+	if err != nil {
+		return 0, 0, errNotInteger
+	}
+	end, err := strconv.ParseInt(endArg, 10, 64)
+	if err != nil {
+		return 0, 0, errNotInteger
+	}
+	return start, end, nil
+}
+
+// parseBitUnit reports whether a BYTE|BIT argument selects bit offsets.
+func parseBitUnit(arg string) (bool, error) {
+	switch strings.ToUpper(arg) {
+	case "BYTE":
+		return false, nil
+	case "BIT":
+		return true, nil
+	default:
+		return false, errors.New("syntax error")
+	}
+}
+
+// resolveBitRange turns the start and end of BITCOUNT or BITPOS, in bytes or
+// bits of a size byte long string, into inclusive bit offsets. Negative
+// offsets count from the end, like in GETRANGE. It reports false for an empty
+// range.
+func resolveBitRange(size, start, end int64, bitUnit bool) (uint64, uint64, bool) {
+	if bitUnit {
+		size *= 8
+	}
+
+	if start < 0 {
+		start += size
+	}
+	if end < 0 {
+		end += size
+	}
+	start, end = max(start, 0), max(end, 0)
+	end = min(end, size-1)
+
+	if start > end {
+		return 0, 0, false
+	}
+	if bitUnit {
+		return uint64(start), uint64(end), true
+	}
+	return uint64(start) * 8, uint64(end)*8 + 7, true
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+	"github.com/0x222fe/codecrafters-redis-go/internal/utils/bitutil"
+)
+
+type bitfieldOpKind int
+
+const (
+	bitfieldGet bitfieldOpKind = iota
+	bitfieldSet
+	bitfieldIncrBy
+)
+
+type bitfieldOp struct {
+	kind     bitfieldOpKind
+	field    bitutil.Field
+	offset   uint64
+	value    int64
+	overflow bitutil.Overflow
+}
+
+// bitfieldHandler implements BITFIELD key [GET type offset] [SET type offset
+// value] [INCRBY type offset increment] [OVERFLOW WRAP|SAT|FAIL] ...
+func bitfieldHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 1 {
+		return errors.New("wrong number of arguments for 'bitfield' command")
+	}
+
+	ops, err := parseBitfieldOps(args[1:], false)
+	if err != nil {
+		return err
+	}
+	return bitfieldGeneric(c, s, args[0], ops)
+}
+
+func bitfieldRoHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 1 {
+		return errors.New("wrong number of arguments for 'bitfield_ro' command")
+	}
+
+	ops, err := parseBitfieldOps(args[1:], true)
+	if err != nil {
+		return err
+	}
+	return bitfieldGeneric(c, s, args[0], ops)
+}
+
+func parseBitfieldOps(args []string, readOnly bool) ([]bitfieldOp, error) {
+	var ops []bitfieldOp
+	overflow := bitutil.OverflowWrap
+
+	for i := 0; i < len(args); i++ {
+		sub := strings.ToUpper(args[i])
+		if readOnly && sub != "GET" {
+			return nil, errors.New("BITFIELD_RO only supports the GET subcommand")
+		}
+
+		if sub == "OVERFLOW" {
+			if i+1 >= len(args) {
+				return nil, errors.New("syntax error")
+			}
+			i++
+
+			switch strings.ToUpper(args[i]) {
+			case "WRAP":
+				overflow = bitutil.OverflowWrap
+			case "SAT":
+				overflow = bitutil.OverflowSat
+			case "FAIL":
+				overflow = bitutil.OverflowFail
+			default:
+				return nil, errors.New("Invalid OVERFLOW type specified")
+			}
+			continue
+		}
+
+		op := bitfieldOp{overflow: overflow}
+		argc := 2
+		switch sub {
+		case "GET":
+			op.kind = bitfieldGet
+		case "SET":
+			op.kind, argc = bitfieldSet, 3
+		case "INCRBY":
+			op.kind, argc = bitfieldIncrBy, 3
+		default:
+			return nil, errors.New("syntax error")
+		}
+		if i+argc >= len(args) {
+			return nil, errors.New("syntax error")
+		}
+
+		field, err := parseBitfieldType(args[i+1])
+		if err != nil {
+			return nil, err
+		}
+		offset, err := parseBitfieldOffset(args[i+2], field)
+		if err != nil {
+			return nil, err
+		}
+		op.field, op.offset = field, offset
+
+		if argc == 3 {
+			if op.value, err = strconv.ParseInt(args[i+3], 10, 64); err != nil {
+				return nil, errNotInteger
+			}
+		}
+
+		ops = append(ops, op)
+		i += argc
+	}
+
+	return ops, nil
+}
+
+// parseBitfieldType parses a type like i5 or u8.
+func parseBitfieldType(arg string) (bitutil.Field, error) {
+	errType := errors.New("Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is.")
+	if len(arg) < 2 {
+		return bitutil.Field{}, errType
+	}
+
+	var field bitutil.Field
+	maxBits := uint64(63)
+	switch arg[0] {
+	case 'i', 'I':
+		field.Signed, maxBits = true, 64
+	case 'u', 'U':
+	default:
+		return field, errType
+	}
+
+	n, err := strconv.ParseUint(arg[1:], 10, 8)
+	if err != nil || n < 1 || n > maxBits {
+		return field, errType
+	}
+	field.Bits = uint(n)
+	return field, nil
+}
+
+// parseBitfieldOffset parses a bit offset, or with a '#' prefix an offset in
+// multiples of the field width. The whole field has to fit in the largest
+// string value.
+func parseBitfieldOffset(arg string, field bitutil.Field) (uint64, error) {
+	limit := uint64(store.MaxStringLength * 8)
+	multiply := strings.HasPrefix(arg, "#")
+	if multiply {
+		arg = arg[1:]
+	}
+
+	offset, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return 0, errBitOffset
+	}
+	if multiply {
+		if offset > limit/uint64(field.Bits) {
+			return 0, errBitOffset
+		}
+		offset *= uint64(field.Bits)
+	}
+
+	if offset > limit-uint64(field.Bits) {
+		return 0, errBitOffset
+	}
+	return offset, nil
+}
+
+// bitfieldGeneric runs ops in order on the string at key, reading it only
+// when every op is a GET. Writes pad the string with zero bytes to hold the
+// highest field written, even if OVERFLOW FAIL leaves it untouched.
+func bitfieldGeneric(c *client.Client, s *state.AppState, key string, ops []bitfieldOp) error {
+	var end uint64
+	for _, op := range ops {
+		if op.kind != bitfieldGet {
+			end = max(end, op.offset+uint64(op.field.Bits))
+		}
+	}
+
+	replies := make([]resp.RESPValue, len(ops))
+	changes := 0
+	apply := func(buf []byte) {
+		for i, op := range ops {
+			cur := op.field.Get(buf, op.offset)
+
+			switch op.kind {
+			case bitfieldGet:
+				replies[i] = resp.NewInt(cur)
+				continue
+			case bitfieldSet:
+				v, ok := op.field.Add(op.value, 0, op.overflow)
+				if !ok {
+					replies[i] = resp.RESPNilBulkString
+					continue
+				}
+				op.field.Set(buf, op.offset, v)
+				replies[i] = resp.NewInt(cur)
+			case bitfieldIncrBy:
+				v, ok := op.field.Add(cur, op.value, op.overflow)
+				if !ok {
+					replies[i] = resp.RESPNilBulkString
+					continue
+				}
+				op.field.Set(buf, op.offset, v)
+				replies[i] = resp.NewInt(v)
+			}
+			changes++
+		}
+	}
+
+	st := s.GetDB(c.DB)
+	if end == 0 {
+		val, _, err := st.GetString(key)
+		if err != nil {
+			return err
+		}
+		apply(val)
+		c.PropagateAs()
+		return writeResponse(c, resp.NewArray(replies))
+	}
+
+	written, err := st.MutateString(key, int((end-1)/8+1), func(buf []byte) bool {
+		apply(buf)
+		return changes > 0
+	})
+	if err != nil {
+		return err
+	}
+
+	if changes > 0 {
+		s.NotifyKeyspaceEvent(config.NotifyString, "setbit", key, c.DB)
+	}
+	if !written {
+		c.PropagateAs()
+	}
+
+	return writeResponse(c, resp.NewArray(replies))
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/utils/bitutil"
+)
+
+var bitOps = map[string]bitutil.Op{
+	"AND": bitutil.OpAnd,
+	"OR":  bitutil.OpOr,
+	"XOR": bitutil.OpXor,
+	"NOT": bitutil.OpNot,
+}
+
+// bitopHandler implements BITOP AND|OR|XOR|NOT destkey key [key ...].
+func bitopHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 3 {
+		return errors.New("wrong number of arguments for 'bitop' command")
+	}
+
+	op, ok := bitOps[strings.ToUpper(args[0])]
+	if !ok {
+		return errors.New("syntax error")
+	}
+	dest, keys := args[1], args[2:]
+	if op == bitutil.OpNot && len(keys) != 1 {
+		return errors.New("BITOP NOT must be called with a single source key.")
+	}
+
+	val, deleted, err := s.GetDB(c.DB).CombineStrings(dest, keys, func(vals [][]byte) []byte {
+		return bitutil.Apply(op, vals)
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(val) > 0:
+		s.NotifyKeyspaceEvent(config.NotifyString, "set", dest, c.DB)
+	case deleted:
+		s.NotifyKeyspaceEvent(config.NotifyGeneric, "del", dest, c.DB)
+	}
+
+	return writeResponse(c, resp.NewInt(int64(len(val))))
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/utils/bitutil"
+)
+
+// bitposHandler implements BITPOS key bit [start [end [BYTE|BIT]]].
+func bitposHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 2 {
+		return errors.New("wrong number of arguments for 'bitpos' command")
+	}
+	if len(args) > 5 {
+		return errors.New("syntax error")
+	}
+
+	bit, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	if bit != 0 && bit != 1 {
+		return errors.New("The bit argument must be 1 or 0.")
+	}
+
+	start, end := int64(0), int64(-1)
+	bitUnit := false
+	switch len(args) {
+	case 3:
+		if start, err = strconv.ParseInt(args[2], 10, 64); err != nil {
+			return errNotInteger
+		}
+	case 4, 5:
+		if start, end, err = parseBitRange(args[2], args[3]); err != nil {
+			return err
+		}
+		if len(args) == 5 {
+			if bitUnit, err = parseBitUnit(args[4]); err != nil {
+				return err
+			}
+		}
+	}
+
+	val, ok, err := s.GetDB(c.DB).GetString(args[0])
+	if err != nil {
+		return err
+	}
+	// INFO: a missing key is an endless run of zeros
+	if !ok {
+		return writeResponse(c, resp.NewInt(-bit))
+	}
+
+	first, last, ok := resolveBitRange(int64(len(val)), start, end, bitUnit)
+	if !ok {
+		return writeResponse(c, resp.NewInt(-1))
+	}
+
+	pos := bitutil.Pos(val, int(bit), first, last)
+	// INFO: without an explicit end the string counts as padded with zeros,
+	// so looking for a 0 in all ones finds the first bit past the end
+	if pos == -1 && bit == 0 && len(args) < 4 {
+		pos = int64(len(val)) * 8
+	}
+	return writeResponse(c, resp.NewInt(pos))
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
 	"github.com/0x222fe/codecrafters-redis-go/internal/store"
@@ -30,7 +32,7 @@ func blpopHandler(c *client.Client, s *state.AppState, args []string) error {
 	}
 
 	for _, key := range keys {
-		v, _, ok := s.GetStore().Get(key)
+		v, _, ok := s.GetDB(c.DB).Get(key)
 		list, parseOk := v.(*store.RedisList)
 		if ok && !parseOk {
 			return store.ERRWrongType
@@ -44,6 +46,8 @@ func blpopHandler(c *client.Client, s *state.AppState, args []string) error {
 		if !ok {
 			continue
 		}
+		s.NotifyKeyspaceEvent(config.NotifyList, "lpop", key, c.DB)
+		c.PropagateAs(command.Command{Name: command.LPOP, Args: []string{key}})
 		writeResponse(
 			c,
 			resputil.BulkStringsToRESPArray([]string{key, items[0]}),
@@ -60,12 +64,12 @@ func blpopHandler(c *client.Client, s *state.AppState, args []string) error {
 
 	defer func() {
 		for _, key := range keys {
-			s.GetStore().UnregisterListPushHandler(key, c.Conn.ID)
+			s.GetDB(c.DB).UnregisterListPushHandler(key, c.Conn.ID)
 		}
 	}()
 
 	for _, key := range keys {
-		v, _, ok := s.GetStore().Get(key)
+		v, _, ok := s.GetDB(c.DB).Get(key)
 		_, parseOk := v.(*store.RedisList)
 		if ok && !parseOk {
 			return store.ERRWrongType
@@ -74,25 +78,51 @@ func blpopHandler(c *client.Client, s *state.AppState, args []string) error {
 		ch := make(chan string, 1)
 
 		go func() {
-			s.GetStore().RegisterListPushHandler(key, c.Conn.ID, ch)
+			s.GetDB(c.DB).RegisterListPushHandler(key, c.Conn.ID, ch)
 			item := <-ch
 			doneChan <- [2]string{key, item}
 
 		}()
 	}
 
+	// INFO: a blocked client must not hold up snapshots, the pop after
+	// waking up is a write of its own
+	if !c.Propagated {
+		s.EndWrite()
+	}
+	var data [2]string
+	woken := false
 	select {
-	case data := <-doneChan:
-		key := data[0]
-		v, _ := s.GetStore().GetExact(key, store.List)
-		list, ok := v.(*store.RedisList)
-		if ok {
-			list.LPop(1)
-		}
-		writeResponse(c, resputil.BulkStringsToRESPArray(data[:]))
-		return nil
+	case data = <-doneChan:
+		woken = true
 	case <-timeoutCh:
+	}
+	if !c.Propagated {
+		s.BeginWrite()
+	}
+
+	if !woken {
+		c.PropagateAs()
 		writeResponse(c, resp.RESPNilArray)
 		return nil
 	}
+
+	key := data[0]
+	v, _ := s.GetDB(c.DB).GetExact(key, store.List)
+	list, ok := v.(*store.RedisList)
+	popped := false
+	if ok {
+		_, popped = list.LPop(1)
+	}
+
+	// INFO: only replay the pop where the master actually removed an
+	// element
+	if popped {
+		s.NotifyKeyspaceEvent(config.NotifyList, "lpop", key, c.DB)
+		c.PropagateAs(command.Command{Name: command.LPOP, Args: []string{key}})
+	} else {
+		c.PropagateAs()
+	}
+	writeResponse(c, resputil.BulkStringsToRESPArray(data[:]))
+	return nil
 }
@@ -3,6 +3,7 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
@@ -39,6 +40,16 @@ func getConfig(appState *state.AppState, cfgName string) (string, error) {
 		return cfg.Dir, nil
 	case "dbfilename":
 		return cfg.Dbfilename, nil
+	case "hz":
+		return strconv.Itoa(cfg.Hz), nil
+	case "databases":
+		return strconv.Itoa(cfg.Databases), nil
+	case "rdbcompression":
+		r := "no"
+		if cfg.RDBCompression {
+			r = "yes"
+		}
+		return r, nil
 	case "appendonly":
 		r := "no"
 		if cfg.AppendOnly {
@@ -51,6 +62,34 @@ func getConfig(appState *state.AppState, cfgName string) (string, error) {
 		return cfg.AppendFilename, nil
 	case "appendfsync":
 		return cfg.AppendFsync, nil
+	case "auto-aof-rewrite-percentage":
+		return strconv.Itoa(cfg.AutoAOFRewritePercentage), nil
+	case "auto-aof-rewrite-min-size":
+		return strconv.FormatInt(cfg.AutoAOFRewriteMinSize, 10), nil
+	case "aof-load-truncated":
+		r := "no"
+		if cfg.AOFLoadTruncated {
+			r = "yes"
+		}
+		return r, nil
+	case "maxmemory":
+		return strconv.FormatInt(cfg.MaxMemory, 10), nil
+	case "maxmemory-policy":
+		return cfg.MaxMemoryPolicy, nil
+	case "maxmemory-samples":
+		return strconv.Itoa(cfg.MaxMemorySamples), nil
+	case "notify-keyspace-events":
+		return cfg.NotifyKeyspaceEvents.String(), nil
+	case "client-output-buffer-limit":
+		return cfg.ClientOutputBufferLimits.String(), nil
+	case "repl-backlog-size":
+		return strconv.FormatInt(cfg.ReplBacklogSize, 10), nil
+	case "repl-diskless-sync":
+		r := "no"
+		if cfg.ReplDisklessSync {
+			r = "yes"
+		}
+		return r, nil
 	default:
 		return "", fmt.Errorf("unknown configuration parameter: %s", cfgName)
 	}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func delHandler(c *client.Client, s *state.AppState, args []string) error {
+	return deleteKeys(c, s, command.DEL, args)
+}
+
+// INFO: values are freed by the garbage collector either way, so UNLINK is
+// DEL under another name.
+func unlinkHandler(c *client.Client, s *state.AppState, args []string) error {
+	return deleteKeys(c, s, command.UNLINK, args)
+}
+
+func deleteKeys(c *client.Client, s *state.AppState, name command.CommandKey, args []string) error {
+	if len(args) == 0 {
+		return errors.New("wrong number of arguments for '" + string(name) + "' command")
+	}
+
+	deleted := make([]string, 0, len(args))
+	for _, key := range args {
+		if s.GetDB(c.DB).Delete(key) {
+			deleted = append(deleted, key)
+			s.NotifyKeyspaceEvent(config.NotifyGeneric, "del", key, c.DB)
+		}
+	}
+
+	// INFO: only keys that actually existed are propagated
+	if len(deleted) == 0 {
+		c.PropagateAs()
+	} else {
+		c.PropagateAs(command.Command{Name: name, Args: deleted})
+	}
+
+	return writeResponse(c, resp.NewInt(int64(len(deleted))))
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func existsHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) == 0 {
+		return errors.New("wrong number of arguments for 'exists' command")
+	}
+
+	// INFO: a key given several times is counted several times, as in Redis
+	count := 0
+	for _, key := range args {
+		if s.GetDB(c.DB).Exists(key) {
+			count++
+		}
+	}
+
+	return writeResponse(c, resp.NewInt(int64(count)))
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func expireHandler(c *client.Client, s *state.AppState, args []string) error {
+	return expireGeneric(c, s, "expire", args, time.Second, false)
+}
+
+func pexpireHandler(c *client.Client, s *state.AppState, args []string) error {
+	return expireGeneric(c, s, "pexpire", args, time.Millisecond, false)
+}
+
+func expireatHandler(c *client.Client, s *state.AppState, args []string) error {
+	return expireGeneric(c, s, "expireat", args, time.Second, true)
+}
+
+func pexpireatHandler(c *client.Client, s *state.AppState, args []string) error {
+	return expireGeneric(c, s, "pexpireat", args, time.Millisecond, true)
+}
+
+// expireGeneric implements the EXPIRE family. The expiry is always propagated
+// as an absolute PEXPIREAT so replicas and the AOF agree on the deadline no
+// matter when they apply it.
+func expireGeneric(c *client.Client, s *state.AppState, name string, args []string, unit time.Duration, absolute bool) error {
+	if len(args) < 2 {
+		return errors.New("wrong number of arguments for '" + name + "' command")
+	}
+
+	key := args[0]
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errors.New("value is not an integer or out of range")
+	}
+
+	var nx, xx, gt, lt bool
+	for _, opt := range args[2:] {
+		switch strings.ToUpper(opt) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GT":
+			gt = true
+		case "LT":
+			lt = true
+		default:
+			return errors.New("Unsupported option " + opt)
+		}
+	}
+	if nx && (xx || gt || lt) {
+		return errors.New("NX and XX, GT or LT options at the same time are not compatible")
+	}
+	if gt && lt {
+		return errors.New("GT and LT options at the same time are not compatible")
+	}
+
+	perMs := int64(unit / time.Millisecond)
+	if n > math.MaxInt64/perMs || n < math.MinInt64/perMs {
+		return errors.New("invalid expire time in '" + name + "' command")
+	}
+	when := n * perMs
+	if !absolute {
+		now := time.Now().UnixMilli()
+		if when > math.MaxInt64-now {
+			return errors.New("invalid expire time in '" + name + "' command")
+		}
+		when += now
+	}
+
+	st := s.GetDB(c.DB)
+	current, ok := st.GetExpire(key)
+	if !ok {
+		c.PropagateAs()
+		return writeResponse(c, resp.NewInt(0))
+	}
+
+	// INFO: a key without expiry counts as an infinite TTL for GT and LT
+	apply := true
+	switch {
+	case nx:
+		apply = current == nil
+	case xx && current == nil:
+		apply = false
+	case gt:
+		apply = current != nil && when > *current
+	case lt:
+		apply = current == nil || when < *current
+	}
+	if !apply {
+		c.PropagateAs()
+		return writeResponse(c, resp.NewInt(0))
+	}
+
+	if when <= time.Now().UnixMilli() {
+		st.Delete(key)
+		s.NotifyKeyspaceEvent(config.NotifyGeneric, "del", key, c.DB)
+		c.PropagateAs(command.Command{Name: command.DEL, Args: []string{key}})
+		return writeResponse(c, resp.NewInt(1))
+	}
+
+	st.SetExpire(key, &when)
+	s.NotifyKeyspaceEvent(config.NotifyGeneric, "expire", key, c.DB)
+	c.PropagateAs(command.Command{
+		Name: command.PEXPIREAT,
+		Args: []string{key, strconv.FormatInt(when, 10)},
+	})
+	return writeResponse(c, resp.NewInt(1))
+}
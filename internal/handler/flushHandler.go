@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func flushdbHandler(c *client.Client, s *state.AppState, args []string) error {
+	if err := parseFlushMode(args); err != nil {
+		return err
+	}
+
+	s.GetDB(c.DB).Flush()
+	return writeResponse(c, resp.NewString("OK"))
+}
+
+func flushallHandler(c *client.Client, s *state.AppState, args []string) error {
+	if err := parseFlushMode(args); err != nil {
+		return err
+	}
+
+	s.FlushAll()
+	return writeResponse(c, resp.NewString("OK"))
+}
+
+// INFO: deleted values are left to the garbage collector, so ASYNC and SYNC
+// both return once the keys are gone from the keyspace.
+func parseFlushMode(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if len(args) == 1 {
+		switch strings.ToUpper(args[0]) {
+		case "ASYNC", "SYNC":
+			return nil
+		}
+	}
+	return errors.New("syntax error")
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
 	"github.com/0x222fe/codecrafters-redis-go/internal/store"
@@ -48,7 +49,13 @@ func geoaddHandler(c *client.Client, s *state.AppState, args []string) error {
 		locations = append(locations, store.SortedSetMember{Score: score, Member: m})
 	}
 
-	count := s.GetStore().AddToSortedSet(key, locations)
+	count, changed, err := s.GetDB(c.DB).AddToSortedSet(key, locations)
+	if err != nil {
+		return err
+	}
+	if changed {
+		s.NotifyKeyspaceEvent(config.NotifyZSet, "zadd", key, c.DB)
+	}
 
 	res := resp.NewInt(int64(count))
 
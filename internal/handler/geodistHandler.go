@@ -17,12 +17,18 @@ func geodistHandler(c *client.Client, s *state.AppState, args []string) error {
 
 	key, a, b := args[0], args[1], args[2]
 
-	aScore, ok := s.GetStore().QuerySortedSetScore(key, a)
+	aScore, ok, err := s.GetDB(c.DB).QuerySortedSetScore(key, a)
+	if err != nil {
+		return err
+	}
 	if !ok {
 		return fmt.Errorf("GEODIST: member %s not found", a)
 	}
 
-	bScore, ok := s.GetStore().QuerySortedSetScore(key, b)
+	bScore, ok, err := s.GetDB(c.DB).QuerySortedSetScore(key, b)
+	if err != nil {
+		return err
+	}
 	if !ok {
 		return fmt.Errorf("GEODIST: member %s not found", b)
 	}
@@ -21,7 +21,10 @@ func geoposHandler(c *client.Client, s *state.AppState, args []string) error {
 	arr := make([]resp.RESPValue, 0, len(locations))
 
 	for _, location := range locations {
-		score, ok := s.GetStore().QuerySortedSetScore(key, location)
+		score, ok, err := s.GetDB(c.DB).QuerySortedSetScore(key, location)
+		if err != nil {
+			return err
+		}
 
 		val := resp.RESPNilArray
 		if ok {
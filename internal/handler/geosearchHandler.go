@@ -68,7 +68,10 @@ func fromLonLat(c *client.Client, s *state.AppState, key string, args []string)
 
 	minScore, maxScore := geoutil.NeighborScoreRange(longitude, latitude, radius)
 
-	locations := s.GetStore().QuerySortedSetMemberByScore(key, minScore, maxScore)
+	locations, err := s.GetDB(c.DB).QuerySortedSetMemberByScore(key, minScore, maxScore)
+	if err != nil {
+		return err
+	}
 
 	result := make([]string, 0, len(locations))
 	for _, location := range locations {
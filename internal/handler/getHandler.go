@@ -4,9 +4,9 @@ import (
 	"errors"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
-	"github.com/0x222fe/codecrafters-redis-go/internal/store"
 )
 
 func getHandler(c *client.Client, s *state.AppState, args []string) error {
@@ -14,13 +14,16 @@ func getHandler(c *client.Client, s *state.AppState, args []string) error {
 		return errors.New("Usage: GET <key>")
 	}
 
-	var res resp.RESPValue
-	value, ok := s.GetStore().GetExact(args[0], store.String)
-	str, parseOk := value.(string)
-	if !ok || !parseOk {
-		res = resp.RESPNilBulkString
+	str, ok, err := s.GetDB(c.DB).GetString(args[0])
+	if err != nil {
+		return err
+	}
+
+	res := resp.RESPNilBulkString
+	if ok {
+		res = resp.NewBulkBytes(str)
 	} else {
-		res = resp.NewBulkString(&str)
+		s.NotifyKeyspaceEvent(config.NotifyKeyMiss, "keymiss", args[0], c.DB)
 	}
 
 	return writeResponse(c, res)
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/utils/bitutil"
+)
+
+func getbitHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 2 {
+		return errors.New("wrong number of arguments for 'getbit' command")
+	}
+
+	offset, err := parseBitOffset(args[1])
+	if err != nil {
+		return err
+	}
+
+	val, _, err := s.GetDB(c.DB).GetString(args[0])
+	if err != nil {
+		return err
+	}
+	return writeResponse(c, resp.NewInt(int64(bitutil.GetBit(val, offset))))
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func getdelHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 1 {
+		return errors.New("wrong number of arguments for 'getdel' command")
+	}
+
+	key := args[0]
+	str, ok, err := s.GetDB(c.DB).GetDel(key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		c.PropagateAs()
+		return writeResponse(c, resp.RESPNilBulkString)
+	}
+
+	s.NotifyKeyspaceEvent(config.NotifyGeneric, "del", key, c.DB)
+	c.PropagateAs(command.Command{Name: command.DEL, Args: []string{key}})
+	return writeResponse(c, resp.NewBulkBytes(str))
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+// getexHandler implements GETEX key [EX|PX|EXAT|PXAT n|PERSIST]. Like the
+// EXPIRE family it propagates the new expiry as PEXPIREAT, or DEL when it is
+// already in the past.
+func getexHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 1 {
+		return errors.New("wrong number of arguments for 'getex' command")
+	}
+
+	key := args[0]
+	var expireAt *int64
+	var persist bool
+	switch opts := args[1:]; {
+	case len(opts) == 0:
+	case len(opts) == 1 && strings.ToUpper(opts[0]) == "PERSIST":
+		persist = true
+	case len(opts) == 2:
+		unit := strings.ToUpper(opts[0])
+		if unit != "EX" && unit != "PX" && unit != "EXAT" && unit != "PXAT" {
+			return errors.New("syntax error")
+		}
+		when, err := parseExpireOption("getex", unit, opts[1])
+		if err != nil {
+			return err
+		}
+		expireAt = &when
+	default:
+		return errors.New("syntax error")
+	}
+
+	st := s.GetDB(c.DB)
+	if expireAt != nil && *expireAt <= time.Now().UnixMilli() {
+		str, ok, err := st.GetDel(key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			c.PropagateAs()
+			return writeResponse(c, resp.RESPNilBulkString)
+		}
+
+		s.NotifyKeyspaceEvent(config.NotifyGeneric, "del", key, c.DB)
+		c.PropagateAs(command.Command{Name: command.DEL, Args: []string{key}})
+		return writeResponse(c, resp.NewBulkBytes(str))
+	}
+
+	str, ok, err := st.GetEx(key, expireAt, expireAt != nil || persist)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		c.PropagateAs()
+		return writeResponse(c, resp.RESPNilBulkString)
+	}
+
+	switch {
+	case expireAt != nil:
+		s.NotifyKeyspaceEvent(config.NotifyGeneric, "expire", key, c.DB)
+		c.PropagateAs(command.Command{
+			Name: command.PEXPIREAT,
+			Args: []string{key, strconv.FormatInt(*expireAt, 10)},
+		})
+	case persist:
+		s.NotifyKeyspaceEvent(config.NotifyGeneric, "persist", key, c.DB)
+		c.PropagateAs(command.Command{Name: command.PERSIST, Args: []string{key}})
+	default:
+		c.PropagateAs()
+	}
+
+	return writeResponse(c, resp.NewBulkBytes(str))
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func getrangeHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 3 {
+		return errors.New("wrong number of arguments for 'getrange' command")
+	}
+
+	start, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	end, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+
+	str, _, err := s.GetDB(c.DB).GetString(args[0])
+	if err != nil {
+		return err
+	}
+
+	return writeResponse(c, resp.NewBulkBytes(substring(str, start, end)))
+}
+
+// substring returns b[start:end] with both ends inclusive, negative
+// offsets counting from the end and out of range offsets clamped, as in
+// Redis' GETRANGE.
+func substring(b []byte, start, end int64) []byte {
+	n := int64(len(b))
+	if n == 0 || (start < 0 && end < 0 && start > end) {
+		return nil
+	}
+
+	if start < 0 {
+		start += n
+	}
+	if end < 0 {
+		end += n
+	}
+	start, end = max(start, 0), max(end, 0)
+	end = min(end, n-1)
+
+	if start > end {
+		return nil
+	}
+	return b[start : end+1]
+}
@@ -0,0 +1,31 @@
+package handler
+
+import "testing"
+
+func TestSubstring(t *testing.T) {
+	tests := []struct {
+		name       string
+		s          string
+		start, end int64
+		want       string
+	}{
+		{"whole string", "This is a string", 0, -1, "This is a string"},
+		{"prefix", "This is a string", 0, 3, "This"},
+		{"negative offsets", "This is a string", -3, -1, "ing"},
+		{"end past the string", "This is a string", 10, 100, "string"},
+		{"start before the string", "This is a string", -100, 3, "This"},
+		{"both before the string", "This is a string", -100, -50, "T"},
+		{"start after end", "This is a string", 5, 3, ""},
+		{"negative start after end", "This is a string", -1, -5, ""},
+		{"start past the string", "This is a string", 16, 20, ""},
+		{"end before the string", "This is a string", 0, -100, "T"},
+		{"empty string", "", 0, -1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(substring([]byte(tt.s), tt.start, tt.end)); got != tt.want {
+				t.Errorf("substring(%q, %d, %d) = %q, want %q", tt.s, tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
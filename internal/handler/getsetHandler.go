@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+func getsetHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 2 {
+		return errors.New("wrong number of arguments for 'getset' command")
+	}
+
+	key, val := args[0], args[1]
+	opts := store.SetOptions{Get: true}
+	res, err := s.GetDB(c.DB).SetString(key, val, opts)
+	if err != nil {
+		return err
+	}
+
+	s.NotifyKeyspaceEvent(config.NotifyString, "set", key, c.DB)
+	c.PropagateAs(setPropagation(key, val, opts))
+
+	if !res.HadOld {
+		return writeResponse(c, resp.RESPNilBulkString)
+	}
+	return writeResponse(c, resp.NewBulkBytes(res.Old))
+}
@@ -14,6 +14,9 @@ type commandSpec struct {
 	handler          commandHandler
 	cmdType          command.CommandType
 	allowedInSubMode bool
+	// denyOOM marks commands that may grow the dataset and are refused
+	// while memory cannot be brought back under maxmemory.
+	denyOOM bool
 }
 type commandHandler func(c *client.Client, s *state.AppState, args []string) error
 
@@ -23,46 +26,107 @@ func (h commandHandler) Handle(c *client.Client, s *state.AppState, cmd command.
 
 var (
 	handlerReg = map[command.CommandKey]commandSpec{
-		command.PING:        {handler: pingHandler, allowedInSubMode: true},
-		command.ECHO:        {handler: echoHandler},
-		command.SET:         {handler: setHandler, cmdType: command.TypeWrite},
-		command.GET:         {handler: getHandler},
-		command.CONFIG:      {handler: configHandler},
-		command.KEYS:        {handler: keysHandler},
-		command.INFO:        {handler: infoHandler},
-		command.REPLCONF:    {handler: replconfHandler},
-		command.PSYNC:       {handler: psyncHandler},
-		command.WAIT:        {handler: waitHandler},
-		command.TYPE:        {handler: typeHandler},
-		command.XADD:        {handler: xaddHandler, cmdType: command.TypeWrite},
-		command.XRANGE:      {handler: xrangeHandler},
-		command.XREAD:       {handler: xreadHandler},
-		command.INCR:        {handler: incrHandler, cmdType: command.TypeWrite},
-		command.MULTI:       {handler: multiHandler},
-		command.LPUSH:       {handler: lpushHandler, cmdType: command.TypeWrite},
-		command.RPUSH:       {handler: rpushHandler, cmdType: command.TypeWrite},
-		command.LRANGE:      {handler: lrangeHandler},
-		command.LLEN:        {handler: llenHandler},
-		command.LPOP:        {handler: lpopHandler, cmdType: command.TypeWrite},
-		command.BLPOP:       {handler: blpopHandler, cmdType: command.TypeWrite},
-		command.RPOP:        {handler: rpopHandler, cmdType: command.TypeWrite},
-		command.SUBSCRIBE:   {handler: subscribeHandler, allowedInSubMode: true},
-		command.UNSUBSCRIBE: {handler: unsubscribeHandler, allowedInSubMode: true},
-		command.PUBLISH:     {handler: publishHandler, cmdType: command.TypeWrite, allowedInSubMode: true},
-		command.ZADD:        {handler: zaddHandler, cmdType: command.TypeWrite},
-		command.ZRANK:       {handler: zrankHandler, cmdType: command.TypeRead},
-		command.ZRANGE:      {handler: zrangeHandler, cmdType: command.TypeRead},
-		command.ZCARD:       {handler: zcardHandler, cmdType: command.TypeRead},
-		command.ZSCORE:      {handler: zscoreHandler, cmdType: command.TypeRead},
-		command.ZREM:        {handler: zremHandler, cmdType: command.TypeWrite},
-		command.GEOADD:      {handler: geoaddHandler, cmdType: command.TypeWrite},
-		command.GEOPOS:      {handler: geoposHandler, cmdType: command.TypeRead},
-		command.GEODIST:     {handler: geodistHandler, cmdType: command.TypeRead},
-		command.GEOSEARCH:   {handler: geosearchHandler, cmdType: command.TypeRead},
-		command.ACL:         {handler: aclHandler, cmdType: command.TypeRead},
-		command.AUTH:        {handler: authHandler, cmdType: command.TypeRead},
-		command.WATCH:       {handler: watchHandler, cmdType: command.TypeRead},
-		command.UNWATCH:     {handler: unwatchHandler, cmdType: command.TypeRead},
+		command.PING:         {handler: pingHandler, allowedInSubMode: true},
+		command.ECHO:         {handler: echoHandler},
+		command.SET:          {handler: setHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.GET:          {handler: getHandler},
+		command.CONFIG:       {handler: configHandler},
+		command.KEYS:         {handler: keysHandler},
+		command.INFO:         {handler: infoHandler},
+		command.REPLCONF:     {handler: replconfHandler},
+		command.PSYNC:        {handler: psyncHandler},
+		command.WAIT:         {handler: waitHandler},
+		command.TYPE:         {handler: typeHandler},
+		command.XADD:         {handler: xaddHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.XRANGE:       {handler: xrangeHandler},
+		command.XREAD:        {handler: xreadHandler},
+		command.INCR:         {handler: incrHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.MULTI:        {handler: multiHandler},
+		command.LPUSH:        {handler: lpushHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.RPUSH:        {handler: rpushHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.LRANGE:       {handler: lrangeHandler},
+		command.LLEN:         {handler: llenHandler},
+		command.LPOP:         {handler: lpopHandler, cmdType: command.TypeWrite},
+		command.BLPOP:        {handler: blpopHandler, cmdType: command.TypeWrite},
+		command.RPOP:         {handler: rpopHandler, cmdType: command.TypeWrite},
+		command.SUBSCRIBE:    {handler: subscribeHandler, allowedInSubMode: true},
+		command.UNSUBSCRIBE:  {handler: unsubscribeHandler, allowedInSubMode: true},
+		command.PSUBSCRIBE:   {handler: psubscribeHandler, allowedInSubMode: true},
+		command.PUNSUBSCRIBE: {handler: punsubscribeHandler, allowedInSubMode: true},
+		command.PUBLISH:      {handler: publishHandler, cmdType: command.TypeWrite, allowedInSubMode: true},
+		command.PUBSUB:       {handler: pubsubHandler},
+		command.SSUBSCRIBE:   {handler: ssubscribeHandler, allowedInSubMode: true},
+		command.SUNSUBSCRIBE: {handler: sunsubscribeHandler, allowedInSubMode: true},
+		command.SPUBLISH:     {handler: spublishHandler, cmdType: command.TypeWrite, allowedInSubMode: true},
+		command.ZADD:         {handler: zaddHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.ZRANK:        {handler: zrankHandler, cmdType: command.TypeRead},
+		command.ZRANGE:       {handler: zrangeHandler, cmdType: command.TypeRead},
+		command.ZCARD:        {handler: zcardHandler, cmdType: command.TypeRead},
+		command.ZSCORE:       {handler: zscoreHandler, cmdType: command.TypeRead},
+		command.ZREM:         {handler: zremHandler, cmdType: command.TypeWrite},
+		command.GEOADD:       {handler: geoaddHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.GEOPOS:       {handler: geoposHandler, cmdType: command.TypeRead},
+		command.GEODIST:      {handler: geodistHandler, cmdType: command.TypeRead},
+		command.GEOSEARCH:    {handler: geosearchHandler, cmdType: command.TypeRead},
+		command.ACL:          {handler: aclHandler, cmdType: command.TypeRead},
+		command.AUTH:         {handler: authHandler, cmdType: command.TypeRead},
+		command.WATCH:        {handler: watchHandler, cmdType: command.TypeRead},
+		command.UNWATCH:      {handler: unwatchHandler, cmdType: command.TypeRead},
+		command.SAVE:         {handler: saveHandler, cmdType: command.TypeRead},
+		command.BGSAVE:       {handler: bgsaveHandler, cmdType: command.TypeRead},
+		command.LASTSAVE:     {handler: lastsaveHandler, cmdType: command.TypeRead},
+		command.BGREWRITEAOF: {handler: bgrewriteaofHandler, cmdType: command.TypeRead},
+		command.REPLICAOF:    {handler: replicaofHandler, cmdType: command.TypeRead},
+		command.SLAVEOF:      {handler: replicaofHandler, cmdType: command.TypeRead},
+		command.DEL:          {handler: delHandler, cmdType: command.TypeWrite},
+		command.UNLINK:       {handler: unlinkHandler, cmdType: command.TypeWrite},
+		command.EXISTS:       {handler: existsHandler, cmdType: command.TypeRead},
+		command.TOUCH:        {handler: touchHandler, cmdType: command.TypeRead},
+		command.EXPIRE:       {handler: expireHandler, cmdType: command.TypeWrite},
+		command.PEXPIRE:      {handler: pexpireHandler, cmdType: command.TypeWrite},
+		command.EXPIREAT:     {handler: expireatHandler, cmdType: command.TypeWrite},
+		command.PEXPIREAT:    {handler: pexpireatHandler, cmdType: command.TypeWrite},
+		command.TTL:          {handler: ttlHandler, cmdType: command.TypeRead},
+		command.PTTL:         {handler: pttlHandler, cmdType: command.TypeRead},
+		command.EXPIRETIME:   {handler: expiretimeHandler, cmdType: command.TypeRead},
+		command.PEXPIRETIME:  {handler: pexpiretimeHandler, cmdType: command.TypeRead},
+		command.PERSIST:      {handler: persistHandler, cmdType: command.TypeWrite},
+		command.SCAN:         {handler: scanHandler, cmdType: command.TypeRead},
+		command.HSCAN:        {handler: hscanHandler, cmdType: command.TypeRead},
+		command.SSCAN:        {handler: sscanHandler, cmdType: command.TypeRead},
+		command.ZSCAN:        {handler: zscanHandler, cmdType: command.TypeRead},
+		command.SELECT:       {handler: selectHandler, cmdType: command.TypeRead},
+		command.MOVE:         {handler: moveHandler, cmdType: command.TypeWrite},
+		command.SWAPDB:       {handler: swapdbHandler, cmdType: command.TypeWrite},
+		command.DBSIZE:       {handler: dbsizeHandler, cmdType: command.TypeRead},
+		command.FLUSHDB:      {handler: flushdbHandler, cmdType: command.TypeWrite},
+		command.FLUSHALL:     {handler: flushallHandler, cmdType: command.TypeWrite},
+		command.DECR:         {handler: decrHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.INCRBY:       {handler: incrbyHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.DECRBY:       {handler: decrbyHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.INCRBYFLOAT:  {handler: incrbyfloatHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.MGET:         {handler: mgetHandler, cmdType: command.TypeRead},
+		command.MSET:         {handler: msetHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.MSETNX:       {handler: msetnxHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.APPEND:       {handler: appendHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.STRLEN:       {handler: strlenHandler, cmdType: command.TypeRead},
+		command.GETRANGE:     {handler: getrangeHandler, cmdType: command.TypeRead},
+		command.SETRANGE:     {handler: setrangeHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.GETSET:       {handler: getsetHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.GETDEL:       {handler: getdelHandler, cmdType: command.TypeWrite},
+		command.GETEX:        {handler: getexHandler, cmdType: command.TypeWrite},
+		command.SETNX:        {handler: setnxHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.SETEX:        {handler: setexHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.PSETEX:       {handler: psetexHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.LCS:          {handler: lcsHandler, cmdType: command.TypeRead},
+		command.OBJECT:       {handler: objectHandler, cmdType: command.TypeRead},
+		command.SETBIT:       {handler: setbitHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.GETBIT:       {handler: getbitHandler, cmdType: command.TypeRead},
+		command.BITCOUNT:     {handler: bitcountHandler, cmdType: command.TypeRead},
+		command.BITPOS:       {handler: bitposHandler, cmdType: command.TypeRead},
+		command.BITOP:        {handler: bitopHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.BITFIELD:     {handler: bitfieldHandler, cmdType: command.TypeWrite, denyOOM: true},
+		command.BITFIELD_RO:  {handler: bitfieldRoHandler, cmdType: command.TypeRead},
 	}
 )
 
@@ -74,13 +138,32 @@ func RunCommand(c *client.Client, s *state.AppState, cmd command.Command) error
 			return errors.New("EXEC without MULTI")
 		}
 
-		resArr, err := c.ExecTransaction(s)
+		if !c.Propagated {
+			s.BeginWrite()
+			defer s.EndWrite()
+		}
+
+		db := c.DB
+		resArr, writes, err := c.ExecTransaction(s)
 		if err != nil {
 			return fmt.Errorf("failed to execute transaction: %w", err)
 		}
 
 		res := resp.NewArray(resArr)
 		writeResponse(c, res)
+
+		switch {
+		case c.Loading:
+			s.DropExpired()
+		case len(writes) > 0:
+			txn := make([]command.Command, 0, len(writes)+2)
+			txn = append(txn, command.Command{Name: command.MULTI})
+			txn = append(txn, writes...)
+			txn = append(txn, command.Command{Name: command.EXEC})
+			s.Propagate(db, txn...)
+		default:
+			s.PropagateExpired()
+		}
 		return nil
 	}
 
@@ -110,6 +193,12 @@ func RunCommand(c *client.Client, s *state.AppState, cmd command.Command) error
 		return errors.New("replica cannot execute write commands")
 	}
 
+	if !c.Propagated && !c.Loading {
+		if err := s.PerformEvictions(); err != nil && spec.denyOOM {
+			return err
+		}
+	}
+
 	if c.IsInTxn() {
 		if cmd.Name == command.MULTI {
 			return errors.New("MULTI calls can not be nested")
@@ -120,7 +209,11 @@ func RunCommand(c *client.Client, s *state.AppState, cmd command.Command) error
 		}
 
 		txnCmds := c.Transaction.Commands
-		txnCmds = append(txnCmds, client.TxnCommand{Command: cmd, Handler: spec.handler})
+		txnCmds = append(txnCmds, client.TxnCommand{
+			Command: cmd,
+			Handler: spec.handler,
+			IsWrite: spec.cmdType == command.TypeWrite,
+		})
 		c.Transaction.Commands = txnCmds
 		res := resp.NewString("QUEUED")
 		writeResponse(c, res)
@@ -131,32 +224,38 @@ func RunCommand(c *client.Client, s *state.AppState, cmd command.Command) error
 		return fmt.Errorf("Can't execute '%s': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING / QUIT / RESET are allowed in this context", cmdName)
 	}
 
+	// INFO: propagated clients are run by the master link or the AOF loader,
+	// which take care of the write themselves
+	if spec.cmdType == command.TypeWrite && !c.Propagated {
+		s.BeginWrite()
+		defer s.EndWrite()
+	}
+
 	err := spec.handler(c, s, cmd.Args)
-	if err != nil {
+	propagated := c.PropagatedCommands(cmd)
+	if c.Loading {
+		s.DropExpired()
 		return err
 	}
-
-	if spec.cmdType == command.TypeWrite && !isReplica {
-		replicaCommand := cmd.EncodeRESP()
-		encoded := replicaCommand.Bytes()
-
-		s.WriteState(func(st *state.ReplicaState) {
-			st.ReplicationOffset += len(encoded)
-		})
-
-		replicas := s.GetReplicas()
-
-		for _, rep := range replicas {
-			if _, err := rep.Conn.Write(encoded); err != nil {
-				fmt.Printf("failed to propagate command to replica %s: %v\n", rep.Conn.RemoteAddr(), err)
-			}
-		}
+	if err != nil || spec.cmdType != command.TypeWrite {
+		propagated = nil
 	}
 
-	return nil
+	// INFO: also without commands to propagate, keys a read or a failed
+	// command found expired still need their DEL
+	s.Propagate(c.DB, propagated...)
+
+	return err
 }
 
+// writeResponse sends res to the client. Replies to propagated clients, the
+// master link and the AOF loader, are dropped, the master does not expect
+// any and would read them as commands.
 func writeResponse(c *client.Client, res resp.RESPValue) error {
+	if c.Propagated {
+		return nil
+	}
+
 	writer := c.GetWriter()
 	err := writer.WriteResp(res)
 	if err != nil {
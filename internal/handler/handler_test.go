@@ -0,0 +1,396 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/aof"
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/connection"
+	"github.com/0x222fe/codecrafters-redis-go/internal/rdb"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+const testAOFFilename = "appendonly.aof"
+
+func newTestState() *state.AppState {
+	cfg := &config.Config{
+		Databases:       1,
+		AppendFilename:  testAOFFilename,
+		ReplBacklogSize: 1 << 20,
+	}
+	return state.NewAppState(&state.ReplicaState{
+		ReplicationID:    state.NewReplicationID(),
+		SecondReplOffset: -1,
+	}, cfg, []*store.Store{store.NewStore()})
+}
+
+func newTestClient() *client.Client {
+	return client.NewClient(context.Background(), connection.NewDiscardConnection())
+}
+
+func waitRewrite(t *testing.T, a *aof.AOF) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for a.Rewriting() {
+		if time.Now().After(deadline) {
+			t.Fatal("AOF rewrite did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+const testClients, testIncrs = 8, 300
+
+// startIncrs runs testIncrs INCRs of key on each of testClients concurrent
+// clients. The returned channel is closed once they have all been run.
+func startIncrs(t *testing.T, s *state.AppState, key string) <-chan struct{} {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	for range testClients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := newTestClient()
+			for range testIncrs {
+				if err := RunCommand(c, s, command.Command{Name: command.INCR, Args: []string{key}}); err != nil {
+					t.Errorf("INCR error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	return done
+}
+
+func assertCounter(t *testing.T, s *state.AppState, key string) {
+	t.Helper()
+
+	got, _, err := s.GetDB(0).GetString(key)
+	if err != nil {
+		t.Fatalf("GetString() error = %v", err)
+	}
+	if want := strconv.Itoa(testClients * testIncrs); string(got) != want {
+		t.Errorf("%s = %s, want %s", key, got, want)
+	}
+}
+
+// TestAOFRewriteDuringWrites checks that a write racing with a rewrite ends
+// up either in the new base file or in the incr file after it, not in both.
+func TestAOFRewriteDuringWrites(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestState()
+	a, err := aof.Open(dir, testAOFFilename, aof.FsyncNo)
+	if err != nil {
+		t.Fatalf("aof.Open() error = %v", err)
+	}
+	s.SetAOF(a)
+
+	done := startIncrs(t, s, "counter")
+
+	rewrites := 0
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			if err := s.RewriteAOF(); err == nil {
+				rewrites++
+			}
+			waitRewrite(t, a)
+		}
+	}
+	waitRewrite(t, a)
+	if err := s.CloseAOF(); err != nil {
+		t.Fatalf("CloseAOF() error = %v", err)
+	}
+	if rewrites == 0 {
+		t.Fatal("no rewrite ran while the writes were in progress")
+	}
+
+	assertCounter(t, loadAOF(t, dir), "counter")
+}
+
+// loadAOF replays the AOF in dir into a new state, like on startup.
+func loadAOF(t *testing.T, dir string) *state.AppState {
+	t.Helper()
+
+	loaded := newTestState()
+	c := newTestClient()
+	c.Propagated = true
+	c.Loading = true
+	err := aof.Load(dir, testAOFFilename, aof.LoadOptions{
+		LoadRDB: func(r io.Reader) error {
+			data, err := rdb.ParseRDB(r)
+			if err != nil {
+				return err
+			}
+			loaded.SetDBs(data.MapToStores(loaded.DBCount()))
+			return nil
+		},
+		Exec: func(cmd command.Command) error {
+			return RunCommand(c, loaded, cmd)
+		},
+	})
+	if err != nil {
+		t.Fatalf("aof.Load() error = %v", err)
+	}
+	return loaded
+}
+
+// TestRestartAOFAfterFullSync checks that once the dataset is replaced by a
+// master snapshot, the AOF replays that snapshot rather than the history of
+// the dataset it replaced.
+func TestRestartAOFAfterFullSync(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestState()
+	a, err := aof.Open(dir, testAOFFilename, aof.FsyncNo)
+	if err != nil {
+		t.Fatalf("aof.Open() error = %v", err)
+	}
+	s.SetAOF(a)
+
+	c := newTestClient()
+	if err := RunCommand(c, s, command.Command{Name: command.SET, Args: []string{"old", "1"}}); err != nil {
+		t.Fatalf("SET error = %v", err)
+	}
+	// INFO: a rewrite of the old dataset still running when the snapshot
+	// arrives has to be waited for, not taken as the new base
+	if err := s.RewriteAOF(); err != nil {
+		t.Fatalf("RewriteAOF() error = %v", err)
+	}
+
+	snapshot := store.NewStore()
+	snapshot.Set("synced", []byte("1"), store.String, nil)
+	s.SetDBs([]*store.Store{snapshot})
+	if err := s.RestartAOF(); err != nil {
+		t.Fatalf("RestartAOF() error = %v", err)
+	}
+	if err := RunCommand(c, s, command.Command{Name: command.SET, Args: []string{"streamed", "1"}}); err != nil {
+		t.Fatalf("SET error = %v", err)
+	}
+
+	waitRewrite(t, a)
+	if err := s.CloseAOF(); err != nil {
+		t.Fatalf("CloseAOF() error = %v", err)
+	}
+
+	loaded := loadAOF(t, dir).GetDB(0)
+	for key, want := range map[string]bool{"old": false, "synced": true, "streamed": true} {
+		if got := loaded.Exists(key); got != want {
+			t.Errorf("%s exists = %v after reloading the AOF, want %v", key, got, want)
+		}
+	}
+}
+
+// partialSyncStream returns what a partial resync from offset sends, the
+// replication stream from offset onwards.
+func partialSyncStream(t *testing.T, s *state.AppState, replID string, offset int) []byte {
+	t.Helper()
+
+	masterEnd, replicaEnd := net.Pipe()
+	conn := connection.NewConnection(masterEnd, nil)
+	rep, _, ok := s.StartPartialSync(conn, replID, offset)
+	if !ok {
+		t.Fatalf("partial resync from offset %d refused", offset)
+	}
+	defer s.RemoveReplica(conn.ID)
+
+	received := make(chan []byte, 1)
+	go func() {
+		b, _ := io.ReadAll(replicaEnd)
+		received <- b
+	}()
+	if err := rep.FinishSync(); err != nil {
+		t.Fatalf("FinishSync() error = %v", err)
+	}
+	masterEnd.Close()
+	return <-received
+}
+
+// TestFullSyncDuringWrites checks that the snapshot sent to a new replica
+// and the stream after the FULLRESYNC offset do not overlap.
+func TestFullSyncDuringWrites(t *testing.T) {
+	s := newTestState()
+	done := startIncrs(t, s, "counter")
+
+	type syncPoint struct {
+		counter int
+		offset  int
+	}
+
+	var points []syncPoint
+	var replID string
+	for running := true; running && len(points) < 2000; {
+		select {
+		case <-done:
+			running = false
+		default:
+			conn := connection.NewDiscardConnection()
+			_, dbs, id, offset := s.StartFullSync(conn)
+			s.RemoveReplica(conn.ID)
+
+			point := syncPoint{offset: offset}
+			for _, e := range dbs[0] {
+				if e.Key == "counter" {
+					point.counter, _ = strconv.Atoi(string(e.Value.([]byte)))
+				}
+			}
+			points = append(points, point)
+			replID = id
+		}
+	}
+	<-done
+
+	incr := command.Command{Name: command.INCR, Args: []string{"counter"}}.EncodeRESP().Bytes()
+	for _, p := range points {
+		stream := partialSyncStream(t, s, replID, p.offset+1)
+		if got := p.counter + bytes.Count(stream, incr); got != testClients*testIncrs {
+			t.Fatalf("snapshot at offset %d holds %d, plus the streamed INCRs makes %d, want %d",
+				p.offset, p.counter, got, testClients*testIncrs)
+		}
+	}
+}
+
+// TestWaitThenPartialResync checks that the GETACK sent by WAIT is part of
+// the replication stream, so a replica that counted it can resume from its
+// offset.
+func TestWaitThenPartialResync(t *testing.T) {
+	s := newTestState()
+
+	masterEnd, replicaEnd := net.Pipe()
+	conn := connection.NewConnection(masterEnd, nil)
+	rep, _, replID, offset := s.StartFullSync(conn)
+
+	received := make(chan []byte, 1)
+	go func() {
+		b, _ := io.ReadAll(replicaEnd)
+		received <- b
+	}()
+	if err := rep.FinishSync(); err != nil {
+		t.Fatalf("FinishSync() error = %v", err)
+	}
+
+	c := newTestClient()
+	for _, cmd := range []command.Command{
+		{Name: command.SET, Args: []string{"k", "v"}},
+		{Name: command.WAIT, Args: []string{"1", "150"}},
+	} {
+		if err := RunCommand(c, s, cmd); err != nil {
+			t.Fatalf("%s error = %v", cmd.Name, err)
+		}
+	}
+	s.RemoveReplica(conn.ID)
+	masterEnd.Close()
+	stream := <-received
+
+	getack := command.Command{Name: command.REPLCONF, Args: []string{"GETACK", "*"}}.EncodeRESP().Bytes()
+	if !bytes.Contains(stream, getack) {
+		t.Fatalf("replication stream %q does not hold the GETACK", stream)
+	}
+
+	if got := partialSyncStream(t, s, replID, offset+1); !bytes.Equal(got, stream) {
+		t.Errorf("backlog from offset %d = %q, want %q", offset, got, stream)
+	}
+	if got := partialSyncStream(t, s, replID, offset+len(stream)+1); len(got) != 0 {
+		t.Errorf("resync after the whole stream sent %q, want nothing", got)
+	}
+}
+
+// TestLazyExpirePropagatesDel checks that a key found expired on access is
+// deleted down the replication stream, ahead of a write replacing it.
+func TestLazyExpirePropagatesDel(t *testing.T) {
+	s := newTestState()
+	conn := connection.NewDiscardConnection()
+	_, _, replID, offset := s.StartFullSync(conn)
+	s.RemoveReplica(conn.ID)
+
+	c := newTestClient()
+	for _, args := range [][]string{
+		{"read", "v", "PX", "1"},
+		{"written", "v", "PX", "1"},
+	} {
+		if err := RunCommand(c, s, command.Command{Name: command.SET, Args: args}); err != nil {
+			t.Fatalf("SET error = %v", err)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	for _, cmd := range []command.Command{
+		{Name: command.GET, Args: []string{"read"}},
+		{Name: command.SET, Args: []string{"written", "new"}},
+	} {
+		if err := RunCommand(c, s, cmd); err != nil {
+			t.Fatalf("%s error = %v", cmd.Name, err)
+		}
+	}
+
+	stream := partialSyncStream(t, s, replID, offset+1)
+	encode := func(name command.CommandKey, args ...string) []byte {
+		return command.Command{Name: name, Args: args}.EncodeRESP().Bytes()
+	}
+	if !bytes.Contains(stream, encode(command.DEL, "read")) {
+		t.Errorf("replication stream %q does not delete the key expired on GET", stream)
+	}
+	del := bytes.Index(stream, encode(command.DEL, "written"))
+	set := bytes.Index(stream, encode(command.SET, "written", "new"))
+	if del < 0 || set < 0 || del > set {
+		t.Errorf("replication stream %q does not delete the expired key before SET replaces it", stream)
+	}
+}
+
+// TestNoEvictionDeniesOOMCommands checks that with noeviction and maxmemory
+// exceeded, only commands that may grow the dataset are refused.
+func TestNoEvictionDeniesOOMCommands(t *testing.T) {
+	cfg := &config.Config{
+		Databases:        1,
+		MaxMemory:        1,
+		MaxMemoryPolicy:  string(store.NoEviction),
+		MaxMemorySamples: 5,
+	}
+	s := state.NewAppState(&state.ReplicaState{
+		ReplicationID:    state.NewReplicationID(),
+		SecondReplOffset: -1,
+	}, cfg, []*store.Store{store.NewStore()})
+	s.GetDB(0).Set("key", []byte("value"), store.String, nil)
+
+	c := newTestClient()
+	for _, tt := range []struct {
+		cmd     command.Command
+		wantOOM bool
+	}{
+		{command.Command{Name: command.SET, Args: []string{"other", "value"}}, true},
+		{command.Command{Name: command.INCR, Args: []string{"counter"}}, true},
+		{command.Command{Name: command.LPUSH, Args: []string{"list", "a"}}, true},
+		{command.Command{Name: command.GET, Args: []string{"key"}}, false},
+		{command.Command{Name: command.EXPIRE, Args: []string{"key", "100"}}, false},
+		{command.Command{Name: command.DEL, Args: []string{"key"}}, false},
+	} {
+		err := RunCommand(c, s, tt.cmd)
+		if gotOOM := errors.Is(err, state.ErrOOM); gotOOM != tt.wantOOM {
+			t.Errorf("%s error = %v, want OOM %v", tt.cmd.Name, err, tt.wantOOM)
+		}
+	}
+
+	if err := RunCommand(c, s, command.Command{Name: command.SET, Args: []string{"other", "value"}}); err != nil {
+		t.Errorf("SET error = %v once under maxmemory", err)
+	}
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+func hscanHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 2 {
+		return errors.New("wrong number of arguments for 'hscan' command")
+	}
+
+	opts, err := parseScanOptions(args[1:], false, true)
+	if err != nil {
+		return err
+	}
+
+	v, t, ok := s.GetDB(c.DB).Get(args[0])
+	if !ok {
+		return writeScanResponse(c, 0, []string{})
+	}
+	h, isHash := v.(*store.RedisHash)
+	if t != store.Hash || !isHash {
+		return store.ERRWrongType
+	}
+
+	fields, next := h.Scan(opts.cursor, opts.count)
+
+	res := make([]string, 0, len(fields)*2)
+	for field, value := range fields {
+		if !opts.matches(field) {
+			continue
+		}
+		res = append(res, field)
+		if !opts.noValues {
+			res = append(res, value)
+		}
+	}
+
+	return writeScanResponse(c, next, res)
+}
@@ -2,42 +2,117 @@ package handler
 
 import (
 	"errors"
+	"math"
 	"strconv"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
-	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
 	"github.com/0x222fe/codecrafters-redis-go/internal/store"
 )
 
+var (
+	errNotInteger = store.ERRNotInteger
+	errNotFloat   = errors.New("value is not a valid float")
+)
+
 func incrHandler(c *client.Client, s *state.AppState, args []string) error {
-	if len(args) < 1 {
-		return errors.New("INCR requires at least 1 argument")
+	if len(args) != 1 {
+		return errors.New("wrong number of arguments for 'incr' command")
 	}
+	return incrGeneric(c, s, "incrby", args[0], 1)
+}
 
-	key := args[0]
+func decrHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 1 {
+		return errors.New("wrong number of arguments for 'decr' command")
+	}
+	return incrGeneric(c, s, "decrby", args[0], -1)
+}
+
+func incrbyHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 2 {
+		return errors.New("wrong number of arguments for 'incrby' command")
+	}
+
+	delta, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	return incrGeneric(c, s, "incrby", args[0], delta)
+}
+
+func decrbyHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 2 {
+		return errors.New("wrong number of arguments for 'decrby' command")
+	}
 
-	val, ok := s.GetStore().GetExact(key, store.String)
-	if !ok {
-		s.GetStore().Set(key, "1", store.String, nil)
-		res := resp.NewInt(1)
-		writeResponse(c, res)
-		return nil
+	delta, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	if delta == math.MinInt64 {
+		return errors.New("decrement would overflow")
+	}
+	return incrGeneric(c, s, "decrby", args[0], -delta)
+}
+
+// incrGeneric adds delta to the integer stored at key, treating a missing key
+// as 0. The key keeps its expiry.
+func incrGeneric(c *client.Client, s *state.AppState, event, key string, delta int64) error {
+	n, err := s.GetDB(c.DB).IncrBy(key, delta)
+	if err != nil {
+		return err
+	}
+
+	s.NotifyKeyspaceEvent(config.NotifyString, event, key, c.DB)
+	return writeResponse(c, resp.NewInt(n))
+}
+
+// incrbyfloatHandler is propagated as a SET of the result so replicas and the
+// AOF do not accumulate floating point differences.
+func incrbyfloatHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 2 {
+		return errors.New("wrong number of arguments for 'incrbyfloat' command")
 	}
 
-	strVal, ok := val.(string)
-	if !ok {
-		return errors.New("value is not a string")
+	key := args[0]
+	delta, err := parseFloatArg(args[1])
+	if err != nil {
+		return err
 	}
 
-	n, err := strconv.ParseInt(strVal, 10, 64)
+	val, err := s.GetDB(c.DB).UpdateString(key, func(old []byte, exists bool) ([]byte, bool, error) {
+		var n float64
+		if exists {
+			cur, err := parseFloatArg(string(old))
+			if err != nil {
+				return nil, false, err
+			}
+			n = cur
+		}
+
+		n += delta
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, false, errors.New("increment would produce NaN or Infinity")
+		}
+		return strconv.AppendFloat(nil, n, 'f', -1, 64), true, nil
+	})
 	if err != nil {
-		return errors.New("value is not an integer or out of range")
+		return err
 	}
 
-	n++
-	s.GetStore().Set(key, strconv.FormatInt(n, 10), store.String, nil)
-	res := resp.NewInt(n)
-	writeResponse(c, res)
-	return nil
+	s.NotifyKeyspaceEvent(config.NotifyString, "incrbyfloat", key, c.DB)
+	c.PropagateAs(command.Command{Name: command.SET, Args: []string{key, string(val), "KEEPTTL"}})
+	return writeResponse(c, resp.NewBulkBytes(val))
+}
+
+func parseFloatArg(arg string) (float64, error) {
+	f, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, errNotFloat
+	}
+	return f, nil
 }
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+// runOnValue runs handler on key holding start, nil for a missing key, and
+// returns the value left at key.
+func runOnValue(t *testing.T, handler commandHandler, start *string, arg string) (string, error) {
+	t.Helper()
+
+	s := newTestState()
+	if start != nil {
+		s.GetDB(0).Set("key", []byte(*start), store.String, nil)
+	}
+	err := handler(newTestClient(), s, []string{"key", arg})
+	val, _, getErr := s.GetDB(0).GetString("key")
+	if getErr != nil {
+		t.Fatalf("GetString() error = %v", getErr)
+	}
+	return string(val), err
+}
+
+func TestIncrbyDecrbyBounds(t *testing.T) {
+	str := func(n int64) *string {
+		s := strconv.FormatInt(n, 10)
+		return &s
+	}
+	maxInt, minInt := strconv.FormatInt(math.MaxInt64, 10), strconv.FormatInt(math.MinInt64, 10)
+
+	tests := []struct {
+		name    string
+		handler commandHandler
+		start   *string
+		arg     string
+		want    string
+		wantErr bool
+	}{
+		{"INCRBY up to the maximum", incrbyHandler, str(math.MaxInt64 - 1), "1", maxInt, false},
+		{"INCRBY past the maximum", incrbyHandler, str(math.MaxInt64), "1", maxInt, true},
+		{"INCRBY the minimum from 0", incrbyHandler, str(0), minInt, minInt, false},
+		{"INCRBY past the minimum", incrbyHandler, str(-1), minInt, "-1", true},
+		{"INCRBY on a missing key", incrbyHandler, nil, maxInt, maxInt, false},
+		{"INCRBY a non integer", incrbyHandler, str(1), "1.5", "1", true},
+		{"DECRBY down to the minimum", decrbyHandler, str(math.MinInt64 + 1), "1", minInt, false},
+		{"DECRBY past the minimum", decrbyHandler, str(math.MinInt64), "1", minInt, true},
+		{"DECRBY a negative past the maximum", decrbyHandler, str(1), strconv.FormatInt(-math.MaxInt64, 10), "1", true},
+		{"DECRBY the maximum from 0", decrbyHandler, str(0), maxInt, strconv.FormatInt(-math.MaxInt64, 10), false},
+		{"DECRBY the minimum", decrbyHandler, str(-1), minInt, "-1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runOnValue(t, tt.handler, tt.start, tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("value = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncrbyfloatFormat(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name    string
+		start   *string
+		arg     string
+		want    string
+		wantErr bool
+	}{
+		{"fraction", str("10.50"), "0.1", "10.6", false},
+		{"exponent", str("5.0e3"), "2.0e2", "5200", false},
+		{"integer result", str("3"), "-3", "0", false},
+		{"no exponent in the result", nil, "1e-5", "0.00001", false},
+		{"large result", nil, "1e20", "100000000000000000000", false},
+		{"negative", str("-1.5"), "-1", "-2.5", false},
+		{"infinite result", str("1.7e308"), "1.7e308", "1.7e308", true},
+		{"not a float", str("abc"), "1", "abc", true},
+		{"infinite increment", str("1"), "inf", "1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runOnValue(t, incrbyfloatHandler, tt.start, tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("value = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
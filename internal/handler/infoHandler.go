@@ -3,6 +3,7 @@ package handler
 import (
 	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
@@ -14,16 +15,29 @@ func infoHandler(c *client.Client, s *state.AppState, args []string) error {
 		return errors.New("INFO requires at least one argument")
 	}
 
-	if args[0] != "replication" {
-		return errors.New("only 'replication' section is supported")
+	var info string
+	switch strings.ToLower(args[0]) {
+	case "replication":
+		info = replicationInfo(s)
+	case "memory":
+		info = memoryInfo(s)
+	case "stats":
+		info = statsInfo(s)
+	default:
+		return errors.New("only 'replication', 'memory' and 'stats' sections are supported")
 	}
 
-	isReplica, repID, repOffset := false, "", 0
-	s.ReadState(func(st state.ReplicaState) {
-		isReplica = st.IsReplica
-		repID = st.ReplicationID
-		repOffset = st.ReplicationOffset
+	res := resp.NewBulkString(&info)
+
+	return writeResponse(c, res)
+}
+
+func replicationInfo(s *state.AppState) string {
+	var st state.ReplicaState
+	s.ReadState(func(rs state.ReplicaState) {
+		st = rs
 	})
+	isReplica := st.IsReplica
 
 	var role string
 	if isReplica {
@@ -34,12 +48,40 @@ func infoHandler(c *client.Client, s *state.AppState, args []string) error {
 
 	info := "# Replication\r\n" +
 		"role:" + role + "\r\n"
-	if !isReplica {
-		info += "master_replid:" + repID + "\r\n" +
-			"master_repl_offset:" + strconv.Itoa(repOffset) + "\r\n"
+
+	// INFO: like Redis, a replica reports the ID of the history it follows
+	repID := st.ReplicationID
+	if isReplica {
+		repID = st.MasterReplicationID
+	}
+	repID2 := st.ReplicationID2
+	if repID2 == "" {
+		repID2 = strings.Repeat("0", 40)
 	}
 
-	res := resp.NewBulkString(&info)
+	info += "master_replid:" + repID + "\r\n" +
+		"master_replid2:" + repID2 + "\r\n" +
+		"master_repl_offset:" + strconv.Itoa(st.ReplicationOffset) + "\r\n" +
+		"second_repl_offset:" + strconv.Itoa(st.SecondReplOffset) + "\r\n"
 
-	return writeResponse(c, res)
+	return info
+}
+
+// INFO: used_memory only covers the dataset, as estimated by the stores
+func memoryInfo(s *state.AppState) string {
+	cfg := s.ReadCfg()
+
+	return "# Memory\r\n" +
+		"used_memory:" + strconv.FormatInt(s.UsedMemory(), 10) + "\r\n" +
+		"maxmemory:" + strconv.FormatInt(cfg.MaxMemory, 10) + "\r\n" +
+		"maxmemory_policy:" + cfg.MaxMemoryPolicy + "\r\n"
+}
+
+func statsInfo(s *state.AppState) string {
+	return "# Stats\r\n" +
+		"pubsub_channels:" + strconv.Itoa(len(s.ActiveChannels(""))) + "\r\n" +
+		"pubsub_patterns:" + strconv.Itoa(s.NumPat()) + "\r\n" +
+		"pubsubshard_channels:" + strconv.Itoa(len(s.ActiveShardChannels(""))) + "\r\n" +
+		"client_output_buffer_limit_disconnections:" + strconv.FormatInt(s.PubSubDisconnections(), 10) + "\r\n" +
+		"pubsub_dropped_messages:" + strconv.FormatInt(s.PubSubDroppedMessages(), 10) + "\r\n"
 }
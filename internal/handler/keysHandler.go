@@ -5,19 +5,27 @@ import (
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/utils/globutil"
 	"github.com/0x222fe/codecrafters-redis-go/internal/utils/resputil"
 )
 
 func keysHandler(c *client.Client, s *state.AppState, args []string) error {
-	if len(args) == 0 {
-		return errors.New("keys requires at least one argument")
+	if len(args) != 1 {
+		return errors.New("keys requires exactly one argument")
 	}
 
-	if args[0] != "*" {
-		return errors.New("only wildcard '*' is supported")
-	}
+	pattern := args[0]
+	keys := s.GetDB(c.DB).Keys()
 
-	keys := s.GetStore().Keys()
+	if pattern != "*" {
+		matched := keys[:0]
+		for _, key := range keys {
+			if globutil.Match(pattern, key) {
+				matched = append(matched, key)
+			}
+		}
+		keys = matched
+	}
 
 	res := resputil.BulkStringsToRESPArray(keys)
 
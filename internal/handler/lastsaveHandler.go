@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func lastsaveHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 0 {
+		return errors.New("wrong number of arguments for 'lastsave' command")
+	}
+
+	return writeResponse(c, resp.NewInt(s.LastSave().Unix()))
+}
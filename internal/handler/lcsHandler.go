@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+// lcsHandler implements LCS key1 key2 [LEN] [IDX] [MINMATCHLEN len]
+// [WITHMATCHLEN]. Missing keys count as empty strings.
+func lcsHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 2 {
+		return errors.New("wrong number of arguments for 'lcs' command")
+	}
+
+	var getLen, getIdx, withMatchLen bool
+	var minMatchLen int64
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "LEN":
+			getLen = true
+		case "IDX":
+			getIdx = true
+		case "WITHMATCHLEN":
+			withMatchLen = true
+		case "MINMATCHLEN":
+			if i+1 >= len(args) {
+				return errors.New("syntax error")
+			}
+			i++
+			n, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil {
+				return errNotInteger
+			}
+			minMatchLen = max(n, 0)
+		default:
+			return errors.New("syntax error")
+		}
+	}
+	if getLen && getIdx {
+		return errors.New("If you want both the length and indexes, please just use IDX.")
+	}
+
+	st := s.GetDB(c.DB)
+	a, _, err := st.GetString(args[0])
+	if err != nil {
+		return err
+	}
+	b, _, err := st.GetString(args[1])
+	if err != nil {
+		return err
+	}
+
+	// INFO: the table holds a uint32 per pair of prefixes, capped like any
+	// other transient allocation at the largest string value
+	if (len(a)+1)*(len(b)+1)*4 > store.MaxStringLength {
+		return errors.New("Insufficient memory, transient memory for LCS exceeds proto-max-bulk-len")
+	}
+
+	table := lcsTable(a, b)
+	total := table[len(a)][len(b)]
+
+	if getLen {
+		return writeResponse(c, resp.NewInt(int64(total)))
+	}
+
+	lcs, matches := lcsBacktrack(a, b, table, minMatchLen)
+	if !getIdx {
+		str := string(lcs)
+		return writeResponse(c, resp.NewBulkString(&str))
+	}
+	return writeResponse(c, lcsIdxReply(matches, total, withMatchLen))
+}
+
+// lcsMatch is a run of the longest common subsequence that is contiguous in
+// both strings, with inclusive offsets.
+type lcsMatch struct {
+	aStart, aEnd, bStart, bEnd int
+}
+
+// lcsBacktrack walks table back from the end like Redis, returning the
+// longest common subsequence of a and b and its matches of at least
+// minMatchLen bytes, from the last one to the first.
+func lcsBacktrack(a, b []byte, table [][]uint32, minMatchLen int64) ([]byte, []lcsMatch) {
+	total := table[len(a)][len(b)]
+	lcs := make([]byte, total)
+	matches := make([]lcsMatch, 0)
+
+	inRange := false
+	var m lcsMatch
+	i, j, idx := len(a), len(b), total
+	for i > 0 && j > 0 {
+		emit := false
+		if a[i-1] == b[j-1] {
+			idx--
+			lcs[idx] = a[i-1]
+			if !inRange {
+				m = lcsMatch{aStart: i - 1, aEnd: i - 1, bStart: j - 1, bEnd: j - 1}
+				inRange = true
+			} else {
+				m.aStart, m.bStart = i-1, j-1
+			}
+			emit = m.aStart == 0 || m.bStart == 0
+			i--
+			j--
+		} else {
+			if table[i-1][j] > table[i][j-1] {
+				i--
+			} else {
+				j--
+			}
+			emit = inRange
+		}
+
+		if emit {
+			if int64(m.aEnd-m.aStart+1) >= minMatchLen {
+				matches = append(matches, m)
+			}
+			inRange = false
+		}
+	}
+	return lcs, matches
+}
+
+// lcsIdxReply returns the reply to LCS IDX: the matches, each as its range
+// in the first and the second string, followed by its length with
+// WITHMATCHLEN, and the length of the whole subsequence.
+func lcsIdxReply(matches []lcsMatch, total uint32, withMatchLen bool) resp.RESPValue {
+	replies := make([]resp.RESPValue, 0, len(matches))
+	for _, m := range matches {
+		match := []resp.RESPValue{
+			resp.NewArray([]resp.RESPValue{resp.NewInt(int64(m.aStart)), resp.NewInt(int64(m.aEnd))}),
+			resp.NewArray([]resp.RESPValue{resp.NewInt(int64(m.bStart)), resp.NewInt(int64(m.bEnd))}),
+		}
+		if withMatchLen {
+			match = append(match, resp.NewInt(int64(m.aEnd-m.aStart+1)))
+		}
+		replies = append(replies, resp.NewArray(match))
+	}
+
+	matchesKey, lenKey := "matches", "len"
+	return resp.NewArray([]resp.RESPValue{
+		resp.NewBulkString(&matchesKey),
+		resp.NewArray(replies),
+		resp.NewBulkString(&lenKey),
+		resp.NewInt(int64(total)),
+	})
+}
+
+// lcsTable returns the dynamic programming table where table[i][j] is the
+// length of the longest common subsequence of a[:i] and b[:j].
+func lcsTable(a, b []byte) [][]uint32 {
+	table := make([][]uint32, len(a)+1)
+	for i := range table {
+		table[i] = make([]uint32, len(b)+1)
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				table[i][j] = table[i-1][j-1] + 1
+			} else {
+				table[i][j] = max(table[i-1][j], table[i][j-1])
+			}
+		}
+	}
+	return table
+}
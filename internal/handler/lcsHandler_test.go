@@ -0,0 +1,84 @@
+package handler
+
+import "testing"
+
+func TestLCSIdx(t *testing.T) {
+	tests := []struct {
+		name         string
+		a, b         string
+		minMatchLen  int64
+		withMatchLen bool
+		wantLCS      string
+		want         string
+	}{
+		{
+			name:    "matches from last to first",
+			a:       "ohmytext",
+			b:       "mynewtext",
+			wantLCS: "mytext",
+			want: "*4\r\n$7\r\nmatches\r\n*2\r\n" +
+				"*2\r\n*2\r\n:4\r\n:7\r\n*2\r\n:5\r\n:8\r\n" +
+				"*2\r\n*2\r\n:2\r\n:3\r\n*2\r\n:0\r\n:1\r\n" +
+				"$3\r\nlen\r\n:6\r\n",
+		},
+		{
+			name:         "MINMATCHLEN and WITHMATCHLEN",
+			a:            "ohmytext",
+			b:            "mynewtext",
+			minMatchLen:  4,
+			withMatchLen: true,
+			wantLCS:      "mytext",
+			want: "*4\r\n$7\r\nmatches\r\n*1\r\n" +
+				"*3\r\n*2\r\n:4\r\n:7\r\n*2\r\n:5\r\n:8\r\n:4\r\n" +
+				"$3\r\nlen\r\n:6\r\n",
+		},
+		{
+			name:         "WITHMATCHLEN on every match",
+			a:            "ohmytext",
+			b:            "mynewtext",
+			withMatchLen: true,
+			wantLCS:      "mytext",
+			want: "*4\r\n$7\r\nmatches\r\n*2\r\n" +
+				"*3\r\n*2\r\n:4\r\n:7\r\n*2\r\n:5\r\n:8\r\n:4\r\n" +
+				"*3\r\n*2\r\n:2\r\n:3\r\n*2\r\n:0\r\n:1\r\n:2\r\n" +
+				"$3\r\nlen\r\n:6\r\n",
+		},
+		{
+			name:        "MINMATCHLEN longer than every match",
+			a:           "ohmytext",
+			b:           "mynewtext",
+			minMatchLen: 5,
+			wantLCS:     "mytext",
+			want:        "*4\r\n$7\r\nmatches\r\n*0\r\n$3\r\nlen\r\n:6\r\n",
+		},
+		{
+			name:    "nothing in common",
+			a:       "abc",
+			b:       "xyz",
+			wantLCS: "",
+			want:    "*4\r\n$7\r\nmatches\r\n*0\r\n$3\r\nlen\r\n:0\r\n",
+		},
+		{
+			name:    "empty string",
+			a:       "",
+			b:       "xyz",
+			wantLCS: "",
+			want:    "*4\r\n$7\r\nmatches\r\n*0\r\n$3\r\nlen\r\n:0\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := []byte(tt.a), []byte(tt.b)
+			table := lcsTable(a, b)
+			lcs, matches := lcsBacktrack(a, b, table, tt.minMatchLen)
+			if string(lcs) != tt.wantLCS {
+				t.Errorf("LCS = %q, want %q", lcs, tt.wantLCS)
+			}
+
+			total := table[len(a)][len(b)]
+			if got := string(lcsIdxReply(matches, total, tt.withMatchLen).Bytes()); got != tt.want {
+				t.Errorf("IDX reply = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -15,7 +15,7 @@ func llenHandler(c *client.Client, s *state.AppState, args []string) error {
 	}
 	key := args[0]
 
-	v, _, ok := s.GetStore().Get(key)
+	v, _, ok := s.GetDB(c.DB).Get(key)
 	if !ok {
 		writeResponse(c, resp.NewInt(0))
 		return nil
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
 	"github.com/0x222fe/codecrafters-redis-go/internal/store"
@@ -17,10 +18,10 @@ func lpushHandler(c *client.Client, s *state.AppState, args []string) error {
 	key, items := args[0], args[1:]
 
 	var list *store.RedisList
-	v, t, ok := s.GetStore().Get(key)
+	v, t, ok := s.GetDB(c.DB).Get(key)
 	if !ok {
 		list = store.NewList()
-		s.GetStore().Set(key, list, store.List, nil)
+		s.GetDB(c.DB).Set(key, list, store.List, nil)
 	} else {
 		l, parseOk := v.(*store.RedisList)
 		if t != store.List || !parseOk {
@@ -30,8 +31,9 @@ func lpushHandler(c *client.Client, s *state.AppState, args []string) error {
 	}
 
 	count := list.LPush(items...)
+	s.NotifyKeyspaceEvent(config.NotifyList, "lpush", key, c.DB)
 
-	store := s.GetStore()
+	store := s.GetDB(c.DB)
 	for _, item := range items {
 		store.NotifyListPush(key, item)
 	}
@@ -28,7 +28,7 @@ func lrangeHandler(c *client.Client, s *state.AppState, args []string) error {
 		return errors.New("invalid end")
 	}
 
-	v, _, ok := s.GetStore().Get(key)
+	v, _, ok := s.GetDB(c.DB).Get(key)
 	list, parseOk := v.(*store.RedisList)
 	if !ok || !parseOk {
 		writeResponse(c, resp.RESPEmptyArray)
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+// INFO: keys holding other types are returned as nil, MGET never fails with
+// WRONGTYPE
+func mgetHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) == 0 {
+		return errors.New("wrong number of arguments for 'mget' command")
+	}
+
+	st := s.GetDB(c.DB)
+	res := make([]resp.RESPValue, len(args))
+	for i, key := range args {
+		str, ok, err := st.GetString(key)
+		if err != nil || !ok {
+			res[i] = resp.RESPNilBulkString
+			continue
+		}
+		res[i] = resp.NewBulkBytes(str)
+	}
+
+	return writeResponse(c, resp.NewArray(res))
+}
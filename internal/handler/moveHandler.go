@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func moveHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 2 {
+		return errors.New("wrong number of arguments for 'move' command")
+	}
+
+	dst, err := strconv.Atoi(args[1])
+	if err != nil {
+		return errors.New("value is not an integer or out of range")
+	}
+	if !s.ValidDB(dst) {
+		return state.ErrDBIndexOutOfRange
+	}
+	if dst == c.DB {
+		return errors.New("source and destination objects are the same")
+	}
+
+	if !s.GetDB(c.DB).MoveTo(args[0], s.GetDB(dst)) {
+		c.PropagateAs()
+		return writeResponse(c, resp.NewInt(0))
+	}
+
+	s.NotifyKeyspaceEvent(config.NotifyGeneric, "move_from", args[0], c.DB)
+	s.NotifyKeyspaceEvent(config.NotifyGeneric, "move_to", args[0], dst)
+	return writeResponse(c, resp.NewInt(1))
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func msetHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) == 0 || len(args)%2 != 0 {
+		return errors.New("wrong number of arguments for 'mset' command")
+	}
+
+	s.GetDB(c.DB).MSet(args, false)
+	notifyMSet(c, s, args)
+
+	return writeResponse(c, resp.NewString("OK"))
+}
+
+func msetnxHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) == 0 || len(args)%2 != 0 {
+		return errors.New("wrong number of arguments for 'msetnx' command")
+	}
+
+	written := s.GetDB(c.DB).MSet(args, true)
+	if !written {
+		c.PropagateAs()
+		return writeResponse(c, resp.NewInt(0))
+	}
+
+	notifyMSet(c, s, args)
+	return writeResponse(c, resp.NewInt(1))
+}
+
+func notifyMSet(c *client.Client, s *state.AppState, pairs []string) {
+	for i := 0; i < len(pairs); i += 2 {
+		s.NotifyKeyspaceEvent(config.NotifyString, "set", pairs[i], c.DB)
+	}
+}
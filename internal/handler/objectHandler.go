@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func objectHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 1 {
+		return errors.New("wrong number of arguments for 'object' command")
+	}
+
+	subcommand := strings.ToUpper(args[0])
+
+	switch subcommand {
+	case "ENCODING":
+		if len(args) != 2 {
+			return errors.New("wrong number of arguments for 'object|encoding' command")
+		}
+
+		encoding, ok := s.GetDB(c.DB).ObjectEncoding(args[1])
+		if !ok {
+			return writeResponse(c, resp.RESPNilBulkString)
+		}
+		return writeResponse(c, resp.NewBulkString(&encoding))
+	default:
+		return errors.New("unknown subcommand: " + subcommand)
+	}
+}
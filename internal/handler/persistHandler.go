@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func persistHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 1 {
+		return errors.New("wrong number of arguments for 'persist' command")
+	}
+
+	st := s.GetDB(c.DB)
+	expireAt, ok := st.GetExpire(args[0])
+	if !ok || expireAt == nil {
+		c.PropagateAs()
+		return writeResponse(c, resp.NewInt(0))
+	}
+
+	st.SetExpire(args[0], nil)
+	s.NotifyKeyspaceEvent(config.NotifyGeneric, "persist", args[0], c.DB)
+	return writeResponse(c, resp.NewInt(1))
+}
@@ -8,9 +8,6 @@ import (
 )
 
 func pingHandler(c *client.Client, s *state.AppState, args []string) error {
-	if c.Propagated {
-		return nil
-	}
 	if c.SubMode {
 		return writeResponse(c, resputil.BulkStringsToRESPArray([]string{"pong", ""}))
 	}
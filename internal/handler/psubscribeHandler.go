@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func psubscribeHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 1 {
+		return errors.New("PSUBSCRIBE requires at least one argument")
+	}
+
+	subMsg := "psubscribe"
+	for _, pattern := range args {
+		count := s.AddPatternSubscriber(c.Conn, pattern)
+		c.Conn.WriteResp(
+			resp.NewArray(
+				[]resp.RESPValue{
+					resp.NewBulkString(&subMsg),
+					resp.NewBulkString(&pattern),
+					resp.NewInt(int64(count)),
+				},
+			),
+		)
+	}
+
+	c.SubMode = true
+
+	return nil
+}
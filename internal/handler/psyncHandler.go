@@ -1,54 +1,136 @@
 package handler
 
 import (
-	"encoding/base64"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/rdb"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
 )
 
+// INFO: same length as a replication ID, as in Redis
+const rdbEOFMarkLen = 40
+
 func psyncHandler(c *client.Client, s *state.AppState, args []string) error {
 	if len(args) < 2 {
 		return errors.New("PSYNC requires at least 2 arguments")
 	}
 
-	if args[0] != "?" || args[1] != "-1" {
-		return errors.New("PSYNC only supports ? -1 for now")
-	}
+	if args[0] != "?" {
+		offset, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid offset: %s", args[1])
+		}
 
-	replicationID, replicationOffset := "", 0
+		if rep, replID, ok := s.StartPartialSync(c.Conn, args[0], offset); ok {
+			if err := writeResponse(c, resp.NewString("CONTINUE "+replID)); err != nil {
+				s.RemoveReplica(c.Conn.ID)
+				return err
+			}
+			fmt.Printf("Partial resynchronization accepted, continuing from offset %d\n", offset)
+			return rep.FinishSync()
+		}
+	}
 
-	s.ReadState(func(st state.ReplicaState) {
-		replicationID = st.ReplicationID
-		replicationOffset = st.ReplicationOffset
-	})
+	rep, dbs, replicationID, replicationOffset := s.StartFullSync(c.Conn)
 
 	psyncRes := resp.NewString("FULLRESYNC " + replicationID + " " + strconv.Itoa(replicationOffset))
 
 	err := writeResponse(c, psyncRes)
+	if err == nil {
+		err = sendRDB(c, s, dbs)
+	}
 	if err != nil {
+		s.RemoveReplica(c.Conn.ID)
 		return err
 	}
 
-	s.AddReplica(c.Conn)
+	return rep.FinishSync()
+}
+
+// sendRDB transfers the snapshot to the replica. With repl-diskless-sync it
+// is encoded straight onto the connection, delimited by a random EOF mark
+// when the replica supports it; otherwise it is saved to disk first and the
+// file is sent.
+func sendRDB(c *client.Client, s *state.AppState, dbs [][]store.SnapshotEntry) error {
+	cfg := s.ReadCfg()
+	opts := rdb.Options{Compression: cfg.RDBCompression}
+
+	if !cfg.ReplDisklessSync {
+		if s.StartSave() {
+			err := saveRDB(s, dbs)
+			s.FinishSave(err == nil)
+			if err != nil {
+				return fmt.Errorf("failed to save RDB for replication: %w", err)
+			}
+			return sendRDBFile(c, cfg.RDBPath())
+		}
+		fmt.Println("Background save in progress, falling back to diskless sync")
+	}
+
+	if c.ReplCapaEOF {
+		markBytes := make([]byte, rdbEOFMarkLen/2)
+		if _, err := rand.Read(markBytes); err != nil {
+			return fmt.Errorf("failed to generate EOF mark: %w", err)
+		}
+		mark := hex.EncodeToString(markBytes)
+
+		if _, err := c.Conn.Write([]byte("$EOF:" + mark + "\r\n")); err != nil {
+			return fmt.Errorf("failed to write RDB header: %w", err)
+		}
+		if err := rdb.EncodeRDB(c.Conn, dbs, opts); err != nil {
+			return fmt.Errorf("failed to stream RDB: %w", err)
+		}
+		if _, err := c.Conn.Write([]byte(mark)); err != nil {
+			return fmt.Errorf("failed to write EOF mark: %w", err)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := rdb.EncodeRDB(&buf, dbs, opts); err != nil {
+		return fmt.Errorf("failed to encode RDB: %w", err)
+	}
 
-	emptyRdb := "UkVESVMwMDEx+glyZWRpcy12ZXIFNy4yLjD6CnJlZGlzLWJpdHPAQPoFY3RpbWXCbQi8ZfoIdXNlZC1tZW3CsMQQAPoIYW9mLWJhc2XAAP/wbjv+wP9aog=="
+	header := fmt.Appendf(nil, "$%d\r\n", buf.Len())
+	if _, err := c.Conn.Write(header); err != nil {
+		return fmt.Errorf("failed to write RDB header: %w", err)
+	}
+
+	if _, err := c.Conn.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write RDB file: %w", err)
+	}
+
+	return nil
+}
+
+func sendRDBFile(c *client.Client, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open RDB file: %w", err)
+	}
+	defer f.Close()
 
-	fileBytes, err := base64.StdEncoding.DecodeString(emptyRdb)
+	info, err := f.Stat()
 	if err != nil {
-		return errors.New("failed to decode RDB file: " + err.Error())
+		return fmt.Errorf("failed to stat RDB file: %w", err)
 	}
 
-	header := fmt.Appendf(nil, "$%d\r\n", len(fileBytes))
+	header := fmt.Appendf(nil, "$%d\r\n", info.Size())
 	if _, err := c.Conn.Write(header); err != nil {
 		return fmt.Errorf("failed to write RDB header: %w", err)
 	}
 
-	if _, err := c.Conn.Write(fileBytes); err != nil {
+	if _, err := io.Copy(c.Conn, f); err != nil {
 		return fmt.Errorf("failed to write RDB file: %w", err)
 	}
 
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
 )
@@ -17,6 +18,14 @@ func publishHandler(c *client.Client, s *state.AppState, args []string) error {
 
 	sent := s.Publish(channel, []byte(message))
 
+	// INFO: messages go down the replication stream so that subscribers of
+	// replicas get them too, but they are not part of the dataset and are
+	// kept out of the AOF
+	c.PropagateAs()
+	if !c.Propagated {
+		s.PropagateToReplicas(command.Command{Name: command.PUBLISH, Args: args})
+	}
+
 	writeResponse(c, resp.NewInt(int64(sent)))
 
 	return nil
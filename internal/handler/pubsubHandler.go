@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/utils/resputil"
+)
+
+func pubsubHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 1 {
+		return errors.New("PUBSUB requires at least 1 argument")
+	}
+
+	subcommand := strings.ToUpper(args[0])
+
+	switch subcommand {
+	case "CHANNELS":
+		return pubsubChannels(c, args[1:], s.ActiveChannels)
+	case "SHARDCHANNELS":
+		return pubsubChannels(c, args[1:], s.ActiveShardChannels)
+	case "NUMSUB":
+		return pubsubNumSub(c, args[1:], s.NumSub)
+	case "SHARDNUMSUB":
+		return pubsubNumSub(c, args[1:], s.ShardNumSub)
+	case "NUMPAT":
+		if len(args) != 1 {
+			return errors.New("PUBSUB NUMPAT requires no arguments")
+		}
+		return writeResponse(c, resp.NewInt(int64(s.NumPat())))
+	default:
+		return errors.New("unknown subcommand: " + subcommand)
+	}
+}
+
+func pubsubChannels(c *client.Client, args []string, list func(pattern string) []string) error {
+	if len(args) > 1 {
+		return errors.New("PUBSUB CHANNELS accepts at most 1 argument")
+	}
+
+	pattern := ""
+	if len(args) == 1 {
+		pattern = args[0]
+	}
+
+	return writeResponse(c, resputil.BulkStringsToRESPArray(list(pattern)))
+}
+
+func pubsubNumSub(c *client.Client, channels []string, count func(channels []string) []int) error {
+	counts := count(channels)
+
+	res := make([]resp.RESPValue, 0, len(channels)*2)
+	for i := range channels {
+		res = append(res, resp.NewBulkString(&channels[i]), resp.NewInt(int64(counts[i])))
+	}
+
+	return writeResponse(c, resp.NewArray(res))
+}
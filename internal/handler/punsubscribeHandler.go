@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func punsubscribeHandler(c *client.Client, s *state.AppState, args []string) error {
+	patterns := args
+	if len(patterns) == 0 {
+		patterns = s.SubscribedPatterns(c.Conn.ID)
+	}
+
+	unsubMsg := "punsubscribe"
+	if len(patterns) == 0 {
+		c.SubMode = s.HasSubscriptions(c.Conn.ID)
+		c.Conn.WriteResp(
+			resp.NewArray(
+				[]resp.RESPValue{
+					resp.NewBulkString(&unsubMsg),
+					resp.NewBulkString(nil),
+					resp.NewInt(0),
+				},
+			),
+		)
+		return nil
+	}
+
+	for _, pattern := range patterns {
+		count := s.UnsubPattern(c.Conn.ID, pattern)
+		c.SubMode = s.HasSubscriptions(c.Conn.ID)
+
+		c.Conn.WriteResp(
+			resp.NewArray(
+				[]resp.RESPValue{
+					resp.NewBulkString(&unsubMsg),
+					resp.NewBulkString(&pattern),
+					resp.NewInt(int64(count)),
+				},
+			),
+		)
+	}
+
+	return nil
+}
@@ -25,6 +25,11 @@ func replconfHandler(c *client.Client, s *state.AppState, args []string) error {
 	case "ACK":
 		return replconfACK(c, s, args[1:])
 	default:
+		for i := 0; i+1 < len(args); i += 2 {
+			if strings.ToLower(args[i]) == "capa" && strings.ToLower(args[i+1]) == "eof" {
+				c.ReplCapaEOF = true
+			}
+		}
 		return writeResponse(c, resp.NewString("OK"))
 	}
 }
@@ -39,8 +44,13 @@ func replconfGETACK(c *client.Client, s *state.AppState, args []string) error {
 		offset = st.ReplicationOffset
 	})
 
+	// INFO: the ACK is the one reply a replica sends back on the master
+	// link, so it bypasses writeResponse
 	command := resputil.BulkStringsToRESPArray([]string{"REPLCONF", "ACK", strconv.Itoa(offset)})
-	return writeResponse(c, command)
+	if err := c.Conn.WriteResp(command); err != nil {
+		return fmt.Errorf("failed to write response: %w", err)
+	}
+	return nil
 }
 
 func replconfACK(c *client.Client, s *state.AppState, args []string) error {
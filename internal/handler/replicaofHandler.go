@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/replication"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func replicaofHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 2 {
+		return errors.New("wrong number of arguments for 'replicaof' command")
+	}
+
+	rc := s.GetReplicationController()
+	if rc == nil {
+		return errors.New("replication is not available")
+	}
+
+	if strings.ToUpper(args[0]) == "NO" && strings.ToUpper(args[1]) == "ONE" {
+		rc.Promote()
+		return writeResponse(c, resp.NewString("OK"))
+	}
+
+	port, err := strconv.Atoi(args[1])
+	if err != nil || port <= 0 || port > 65535 {
+		return errors.New("Invalid master port")
+	}
+
+	err = rc.ReplicaOf(args[0], port)
+	if err != nil {
+		if errors.Is(err, replication.ErrAlreadyConnected) {
+			return writeResponse(c, resp.NewString("OK Already connected to specified master"))
+		}
+		return err
+	}
+
+	return writeResponse(c, resp.NewString("OK"))
+}
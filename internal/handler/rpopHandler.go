@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
 	"github.com/0x222fe/codecrafters-redis-go/internal/store"
@@ -28,7 +29,7 @@ func rpopHandler(c *client.Client, s *state.AppState, args []string) error {
 		count = c
 	}
 
-	v, _, ok := s.GetStore().Get(key)
+	v, _, ok := s.GetDB(c.DB).Get(key)
 	if !ok {
 		writeResponse(c, resp.RESPNilBulkString)
 		return nil
@@ -43,6 +44,7 @@ func rpopHandler(c *client.Client, s *state.AppState, args []string) error {
 		writeResponse(c, resp.RESPNilBulkString)
 		return nil
 	}
+	s.NotifyKeyspaceEvent(config.NotifyList, "rpop", key, c.DB)
 
 	if count == 1 {
 		writeResponse(c, resp.NewBulkString(&vals[0]))
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/rdb"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+var (
+	saveInProgressErr = errors.New("Background save already in progress")
+)
+
+func saveHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 0 {
+		return errors.New("wrong number of arguments for 'save' command")
+	}
+
+	if !s.StartSave() {
+		return saveInProgressErr
+	}
+
+	err := saveRDB(s, s.Snapshot())
+	s.FinishSave(err == nil)
+	if err != nil {
+		return fmt.Errorf("failed to save RDB file: %w", err)
+	}
+
+	return writeResponse(c, resp.NewString("OK"))
+}
+
+func saveRDB(s *state.AppState, dbs [][]store.SnapshotEntry) error {
+	cfg := s.ReadCfg()
+	filename := cfg.RDBPath()
+
+	opts := rdb.Options{Compression: cfg.RDBCompression}
+	if err := rdb.WriteRDBFile(filename, dbs, opts); err != nil {
+		return err
+	}
+
+	fmt.Printf("DB saved on disk: %s\n", filename)
+	return nil
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/utils/globutil"
+	"github.com/0x222fe/codecrafters-redis-go/internal/utils/resputil"
+)
+
+type scanOptions struct {
+	cursor   uint64
+	count    int
+	match    string
+	valType  string
+	noValues bool
+}
+
+// parseScanOptions parses `cursor [MATCH pattern] [COUNT count]` plus TYPE
+// for SCAN and NOVALUES for HSCAN when allowed.
+func parseScanOptions(args []string, allowType, allowNoValues bool) (scanOptions, error) {
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return scanOptions{}, errors.New("invalid cursor")
+	}
+
+	opts := scanOptions{cursor: cursor, count: 10}
+	for i := 1; i < len(args); i++ {
+		opt := strings.ToUpper(args[i])
+		switch {
+		case opt == "MATCH" && i+1 < len(args):
+			opts.match = args[i+1]
+			i++
+		case opt == "COUNT" && i+1 < len(args):
+			count, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return scanOptions{}, errors.New("value is not an integer or out of range")
+			}
+			if count < 1 {
+				return scanOptions{}, errors.New("syntax error")
+			}
+			opts.count = count
+			i++
+		case opt == "TYPE" && allowType && i+1 < len(args):
+			opts.valType = strings.ToLower(args[i+1])
+			i++
+		case opt == "NOVALUES" && allowNoValues:
+			opts.noValues = true
+		default:
+			return scanOptions{}, errors.New("syntax error")
+		}
+	}
+
+	return opts, nil
+}
+
+func (opts scanOptions) matches(s string) bool {
+	return opts.match == "" || opts.match == "*" || globutil.Match(opts.match, s)
+}
+
+func writeScanResponse(c *client.Client, cursor uint64, elements []string) error {
+	next := strconv.FormatUint(cursor, 10)
+	return writeResponse(c, resp.NewArray([]resp.RESPValue{
+		resp.NewBulkString(&next),
+		resputil.BulkStringsToRESPArray(elements),
+	}))
+}
+
+func scanHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 1 {
+		return errors.New("wrong number of arguments for 'scan' command")
+	}
+
+	opts, err := parseScanOptions(args, true, false)
+	if err != nil {
+		return err
+	}
+
+	st := s.GetDB(c.DB)
+	keys, next := st.Scan(opts.cursor, opts.count)
+
+	res := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !opts.matches(key) {
+			continue
+		}
+		if opts.valType != "" && st.Type(key) != opts.valType {
+			continue
+		}
+		res = append(res, key)
+	}
+
+	return writeScanResponse(c, next, res)
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func selectHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 1 {
+		return errors.New("wrong number of arguments for 'select' command")
+	}
+
+	db, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errors.New("value is not an integer or out of range")
+	}
+	if !s.ValidDB(db) {
+		return state.ErrDBIndexOutOfRange
+	}
+
+	c.DB = db
+	return writeResponse(c, resp.NewString("OK"))
+}
@@ -2,14 +2,16 @@ package handler
 
 import (
 	"errors"
-	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
-	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
 	"github.com/0x222fe/codecrafters-redis-go/internal/store"
 )
 
@@ -18,35 +20,176 @@ func setHandler(c *client.Client, s *state.AppState, args []string) error {
 		return errors.New("SET requires at least two arguments")
 	}
 
-	var expMillis int64
-	var err error
+	key, val := args[0], args[1]
+	opts, err := parseSetOptions(args[2:])
+	if err != nil {
+		return err
+	}
+
+	res, err := s.GetDB(c.DB).SetString(key, val, opts)
+	if err != nil {
+		return err
+	}
+
+	if res.Written {
+		s.NotifyKeyspaceEvent(config.NotifyString, "set", key, c.DB)
+		if opts.ExpireAt != nil {
+			s.NotifyKeyspaceEvent(config.NotifyGeneric, "expire", key, c.DB)
+		}
+		c.PropagateAs(setPropagation(key, val, opts))
+	} else {
+		c.PropagateAs()
+	}
+
+	switch {
+	case opts.Get && res.HadOld:
+		return writeResponse(c, resp.NewBulkBytes(res.Old))
+	case opts.Get, !res.Written:
+		return writeResponse(c, resp.RESPNilBulkString)
+	default:
+		return writeResponse(c, resp.NewString("OK"))
+	}
+}
+
+// parseSetOptions parses [NX|XX] [GET] [EX|PX|EXAT|PXAT|KEEPTTL], in any
+// order, resolving the expiry to an absolute time. Like Redis, an option may
+// be repeated, the last expiry of a repeated one wins.
+func parseSetOptions(args []string) (store.SetOptions, error) {
+	var opts store.SetOptions
+	// INFO: the expiry option given so far, empty if none
+	var expireOpt string
 
-	if len(args) > 2 {
-		switch strings.ToUpper(args[2]) {
-		case "PX":
-			expMillis, err = strconv.ParseInt(args[3], 10, 64)
-			if err != nil || expMillis < 0 {
-				return fmt.Errorf("invalid expiration time: %w", err)
+	for i := 0; i < len(args); i++ {
+		opt := strings.ToUpper(args[i])
+		switch opt {
+		case "NX", "XX":
+			cond := store.SetIfNotExists
+			if opt == "XX" {
+				cond = store.SetIfExists
 			}
-		case "EX":
-			expSeconds, err := strconv.ParseInt(args[3], 10, 64)
-			if err != nil || expSeconds < 0 {
-				return fmt.Errorf("invalid expiration time: %w", err)
+			if opts.Condition != store.SetAlways && opts.Condition != cond {
+				return opts, errors.New("syntax error")
 			}
-			expMillis = expSeconds * 1000
+			opts.Condition = cond
+		case "GET":
+			opts.Get = true
+		case "KEEPTTL":
+			if expireOpt != "" && expireOpt != opt {
+				return opts, errors.New("syntax error")
+			}
+			opts.KeepTTL, expireOpt = true, opt
+		case "EX", "PX", "EXAT", "PXAT":
+			if (expireOpt != "" && expireOpt != opt) || i+1 >= len(args) {
+				return opts, errors.New("syntax error")
+			}
+			i++
+
+			expireAt, err := parseExpireOption("set", opt, args[i])
+			if err != nil {
+				return opts, err
+			}
+			opts.ExpireAt, expireOpt = &expireAt, opt
+		default:
+			return opts, errors.New("syntax error")
 		}
 	}
 
-	var expireAt *int64
-	if expMillis > 0 {
-		expireAt = new(int64)
-		*expireAt = time.Now().Add(time.Duration(expMillis) * time.Millisecond).UnixMilli()
+	return opts, nil
+}
+
+// parseExpireOption resolves the argument of an EX, PX, EXAT or PXAT option
+// of command name to an absolute unix time in milliseconds.
+func parseExpireOption(name, unit, arg string) (int64, error) {
+	errExpireTime := errors.New("invalid expire time in '" + name + "' command")
+
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, errNotInteger
+	}
+	if n <= 0 {
+		return 0, errExpireTime
 	}
 
-	s.GetStore().Set(args[0], args[1], store.String, expireAt)
+	if unit == "EX" || unit == "EXAT" {
+		if n > math.MaxInt64/1000 {
+			return 0, errExpireTime
+		}
+		n *= 1000
+	}
 
-	if c.Propagated {
-		return nil
+	if unit == "EX" || unit == "PX" {
+		now := time.Now().UnixMilli()
+		if n > math.MaxInt64-now {
+			return 0, errExpireTime
+		}
+		n += now
 	}
+
+	return n, nil
+}
+
+// setPropagation rewrites a SET that wrote its key into the form replicas and
+// the AOF apply. The condition and GET were already evaluated here, and a
+// relative expiry becomes PXAT so replication lag does not extend the TTL.
+func setPropagation(key, val string, opts store.SetOptions) command.Command {
+	args := []string{key, val}
+	switch {
+	case opts.ExpireAt != nil:
+		args = append(args, "PXAT", strconv.FormatInt(*opts.ExpireAt, 10))
+	case opts.KeepTTL:
+		args = append(args, "KEEPTTL")
+	}
+	return command.Command{Name: command.SET, Args: args}
+}
+
+func setnxHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 2 {
+		return errors.New("wrong number of arguments for 'setnx' command")
+	}
+
+	key, val := args[0], args[1]
+	opts := store.SetOptions{Condition: store.SetIfNotExists}
+	res, err := s.GetDB(c.DB).SetString(key, val, opts)
+	if err != nil {
+		return err
+	}
+
+	if !res.Written {
+		c.PropagateAs()
+		return writeResponse(c, resp.NewInt(0))
+	}
+
+	s.NotifyKeyspaceEvent(config.NotifyString, "set", key, c.DB)
+	c.PropagateAs(setPropagation(key, val, opts))
+	return writeResponse(c, resp.NewInt(1))
+}
+
+func setexHandler(c *client.Client, s *state.AppState, args []string) error {
+	return setexGeneric(c, s, "setex", "EX", args)
+}
+
+func psetexHandler(c *client.Client, s *state.AppState, args []string) error {
+	return setexGeneric(c, s, "psetex", "PX", args)
+}
+
+func setexGeneric(c *client.Client, s *state.AppState, name, unit string, args []string) error {
+	if len(args) != 3 {
+		return errors.New("wrong number of arguments for '" + name + "' command")
+	}
+
+	key, val := args[0], args[2]
+	expireAt, err := parseExpireOption(name, unit, args[1])
+	if err != nil {
+		return err
+	}
+
+	opts := store.SetOptions{ExpireAt: &expireAt}
+	if _, err := s.GetDB(c.DB).SetString(key, val, opts); err != nil {
+		return err
+	}
+
+	s.NotifyKeyspaceEvent(config.NotifyString, "set", key, c.DB)
+	s.NotifyKeyspaceEvent(config.NotifyGeneric, "expire", key, c.DB)
+	c.PropagateAs(setPropagation(key, val, opts))
 	return writeResponse(c, resp.NewString("OK"))
 }
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+func TestParseSetOptions(t *testing.T) {
+	maxSeconds := strconv.FormatInt(math.MaxInt64/1000, 10)
+
+	tests := []struct {
+		args      string
+		condition store.SetCondition
+		get       bool
+		keepTTL   bool
+		// INFO: expected expiry in milliseconds, after now when relative
+		expire   int64
+		relative bool
+		wantErr  bool
+	}{
+		{args: ""},
+		{args: "NX", condition: store.SetIfNotExists},
+		{args: "xx", condition: store.SetIfExists},
+		{args: "NX NX", condition: store.SetIfNotExists},
+		{args: "NX XX", wantErr: true},
+		{args: "XX GET NX", wantErr: true},
+		{args: "GET XX", condition: store.SetIfExists, get: true},
+		{args: "EX 10", expire: 10000, relative: true},
+		{args: "PX 10", expire: 10, relative: true},
+		{args: "EXAT 10", expire: 10000},
+		{args: "PXAT 10", expire: 10},
+		{args: "PX 10 NX GET", condition: store.SetIfNotExists, get: true, expire: 10, relative: true},
+		{args: "EX 10 EX 20", expire: 20000, relative: true},
+		{args: "EX 10 PX 20", wantErr: true},
+		{args: "EXAT 10 PXAT 20", wantErr: true},
+		{args: "EX", wantErr: true},
+		{args: "PX NX", wantErr: true},
+		{args: "KEEPTTL", keepTTL: true},
+		{args: "KEEPTTL KEEPTTL", keepTTL: true},
+		{args: "KEEPTTL GET XX", condition: store.SetIfExists, get: true, keepTTL: true},
+		{args: "KEEPTTL EX 10", wantErr: true},
+		{args: "PXAT 10 KEEPTTL", wantErr: true},
+		{args: "EX 0", wantErr: true},
+		{args: "PX -1", wantErr: true},
+		{args: "EXAT 0", wantErr: true},
+		{args: "EX 1.5", wantErr: true},
+		{args: "EXAT " + maxSeconds, expire: math.MaxInt64 / 1000 * 1000},
+		{args: "EXAT 9223372036854776", wantErr: true},
+		{args: "EX " + maxSeconds, wantErr: true},
+		{args: "PX 9223372036854775807", wantErr: true},
+		{args: "PXAT 9223372036854775807", expire: math.MaxInt64},
+		{args: "EX 10 FOO", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			before := time.Now().UnixMilli()
+			opts, err := parseSetOptions(strings.Fields(tt.args))
+			after := time.Now().UnixMilli()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSetOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if opts.Condition != tt.condition || opts.Get != tt.get || opts.KeepTTL != tt.keepTTL {
+				t.Errorf("parseSetOptions() condition = %v, get = %v, keepTTL = %v, want %v, %v, %v",
+					opts.Condition, opts.Get, opts.KeepTTL, tt.condition, tt.get, tt.keepTTL)
+			}
+
+			switch {
+			case tt.expire == 0:
+				if opts.ExpireAt != nil {
+					t.Errorf("parseSetOptions() expireAt = %d, want none", *opts.ExpireAt)
+				}
+			case opts.ExpireAt == nil:
+				t.Errorf("parseSetOptions() has no expiry, want %d", tt.expire)
+			case tt.relative:
+				if got := *opts.ExpireAt; got < before+tt.expire || got > after+tt.expire {
+					t.Errorf("parseSetOptions() expireAt = %d, want %d after now", got, tt.expire)
+				}
+			case *opts.ExpireAt != tt.expire:
+				t.Errorf("parseSetOptions() expireAt = %d, want %d", *opts.ExpireAt, tt.expire)
+			}
+		})
+	}
+}
+
+func TestParseExpireOption(t *testing.T) {
+	tests := []struct {
+		unit, arg string
+		want      int64
+		wantErr   string
+	}{
+		{unit: "EXAT", arg: "1", want: 1000},
+		{unit: "PXAT", arg: "1", want: 1},
+		{unit: "EXAT", arg: "9223372036854775", want: 9223372036854775000},
+		{unit: "EXAT", arg: "9223372036854776", wantErr: "invalid expire time in 'set' command"},
+		{unit: "EX", arg: "9223372036854775", wantErr: "invalid expire time in 'set' command"},
+		{unit: "PX", arg: "9223372036854775807", wantErr: "invalid expire time in 'set' command"},
+		{unit: "PX", arg: "0", wantErr: "invalid expire time in 'set' command"},
+		{unit: "EXAT", arg: "-5", wantErr: "invalid expire time in 'set' command"},
+		{unit: "EX", arg: "ten", wantErr: errNotInteger.Error()},
+		{unit: "PX", arg: "9223372036854775808", wantErr: errNotInteger.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.unit+" "+tt.arg, func(t *testing.T) {
+			got, err := parseExpireOption("set", tt.unit, tt.arg)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("parseExpireOption() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseExpireOption() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseExpireOption() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+	"github.com/0x222fe/codecrafters-redis-go/internal/utils/bitutil"
+)
+
+var errBitOffset = errors.New("bit offset is not an integer or out of range")
+
+func setbitHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 3 {
+		return errors.New("wrong number of arguments for 'setbit' command")
+	}
+
+	key := args[0]
+	offset, err := parseBitOffset(args[1])
+	if err != nil {
+		return err
+	}
+	if args[2] != "0" && args[2] != "1" {
+		return errors.New("bit is not an integer or out of range")
+	}
+	bit := int(args[2][0] - '0')
+
+	var old int
+	_, err = s.GetDB(c.DB).MutateString(key, int(offset/8+1), func(buf []byte) bool {
+		old = bitutil.SetBit(buf, offset, bit)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	s.NotifyKeyspaceEvent(config.NotifyString, "setbit", key, c.DB)
+	return writeResponse(c, resp.NewInt(int64(old)))
+}
+
+// parseBitOffset parses a bit offset, which has to address a bit within the
+// largest string value.
+func parseBitOffset(arg string) (uint64, error) {
+	offset, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil || offset >= store.MaxStringLength*8 {
+		return 0, errBitOffset
+	}
+	return offset, nil
+}
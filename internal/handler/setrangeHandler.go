@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+func setrangeHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 3 {
+		return errors.New("wrong number of arguments for 'setrange' command")
+	}
+
+	key, patch := args[0], args[2]
+	offset, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return errNotInteger
+	}
+	if offset < 0 {
+		return errors.New("offset is out of range")
+	}
+	if offset+int64(len(patch)) > store.MaxStringLength {
+		return store.ERRStringTooLong
+	}
+
+	written := false
+	val, err := s.GetDB(c.DB).UpdateString(key, func(old []byte, exists bool) ([]byte, bool, error) {
+		// INFO: an empty patch changes nothing and never creates the key
+		if len(patch) == 0 {
+			return old, false, nil
+		}
+
+		buf := make([]byte, max(len(old), int(offset)+len(patch)))
+		copy(buf, old)
+		copy(buf[offset:], patch)
+
+		written = true
+		return buf, true, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if written {
+		s.NotifyKeyspaceEvent(config.NotifyString, "setrange", key, c.DB)
+	} else {
+		c.PropagateAs()
+	}
+
+	return writeResponse(c, resp.NewInt(int64(len(val))))
+}
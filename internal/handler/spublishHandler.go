@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func spublishHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 2 {
+		return errors.New("SPUBLISH requires exactly 2 arguments")
+	}
+
+	channel, message := args[0], args[1]
+
+	sent := s.SPublish(channel, []byte(message))
+
+	// INFO: replicated but kept out of the AOF, see publishHandler
+	c.PropagateAs()
+	if !c.Propagated {
+		s.PropagateToReplicas(command.Command{Name: command.SPUBLISH, Args: args})
+	}
+
+	writeResponse(c, resp.NewInt(int64(sent)))
+
+	return nil
+}
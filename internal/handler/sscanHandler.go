@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+func sscanHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 2 {
+		return errors.New("wrong number of arguments for 'sscan' command")
+	}
+
+	opts, err := parseScanOptions(args[1:], false, false)
+	if err != nil {
+		return err
+	}
+
+	v, t, ok := s.GetDB(c.DB).Get(args[0])
+	if !ok {
+		return writeScanResponse(c, 0, []string{})
+	}
+	set, isSet := v.(*store.RedisSet)
+	if t != store.Set || !isSet {
+		return store.ERRWrongType
+	}
+
+	members, next := set.Scan(opts.cursor, opts.count)
+
+	res := members[:0]
+	for _, m := range members {
+		if opts.matches(m) {
+			res = append(res, m)
+		}
+	}
+
+	return writeScanResponse(c, next, res)
+}
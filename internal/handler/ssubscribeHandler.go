@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func ssubscribeHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 1 {
+		return errors.New("SSUBSCRIBE requires at least one argument")
+	}
+
+	subMsg := "ssubscribe"
+	for _, channel := range args {
+		count := s.AddShardSubscriber(c.Conn, channel)
+		c.Conn.WriteResp(
+			resp.NewArray(
+				[]resp.RESPValue{
+					resp.NewBulkString(&subMsg),
+					resp.NewBulkString(&channel),
+					resp.NewInt(int64(count)),
+				},
+			),
+		)
+	}
+
+	c.SubMode = true
+
+	return nil
+}
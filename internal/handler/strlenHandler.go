@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func strlenHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 1 {
+		return errors.New("wrong number of arguments for 'strlen' command")
+	}
+
+	str, _, err := s.GetDB(c.DB).GetString(args[0])
+	if err != nil {
+		return err
+	}
+
+	return writeResponse(c, resp.NewInt(int64(len(str))))
+}
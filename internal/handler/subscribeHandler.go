@@ -15,13 +15,13 @@ func subscribeHandler(c *client.Client, s *state.AppState, args []string) error
 
 	subMsg := "subscribe"
 	for _, channel := range args {
-		sub := s.AddSubscriber(c.Conn, channel)
+		count := s.AddSubscriber(c.Conn, channel)
 		c.Conn.WriteResp(
 			resp.NewArray(
 				[]resp.RESPValue{
 					resp.NewBulkString(&subMsg),
 					resp.NewBulkString(&channel),
-					resp.NewInt(int64(len(sub.Channels))),
+					resp.NewInt(int64(count)),
 				},
 			),
 		)
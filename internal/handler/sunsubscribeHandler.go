@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func sunsubscribeHandler(c *client.Client, s *state.AppState, args []string) error {
+	channels := args
+	if len(channels) == 0 {
+		channels = s.SubscribedShardChannels(c.Conn.ID)
+	}
+
+	unsubMsg := "sunsubscribe"
+	if len(channels) == 0 {
+		c.SubMode = s.HasSubscriptions(c.Conn.ID)
+		c.Conn.WriteResp(
+			resp.NewArray(
+				[]resp.RESPValue{
+					resp.NewBulkString(&unsubMsg),
+					resp.NewBulkString(nil),
+					resp.NewInt(0),
+				},
+			),
+		)
+		return nil
+	}
+
+	for _, channel := range channels {
+		count := s.UnsubShardChannel(c.Conn.ID, channel)
+		c.SubMode = s.HasSubscriptions(c.Conn.ID)
+
+		c.Conn.WriteResp(
+			resp.NewArray(
+				[]resp.RESPValue{
+					resp.NewBulkString(&unsubMsg),
+					resp.NewBulkString(&channel),
+					resp.NewInt(int64(count)),
+				},
+			),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func swapdbHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) != 2 {
+		return errors.New("wrong number of arguments for 'swapdb' command")
+	}
+
+	a, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errors.New("invalid first DB index")
+	}
+	b, err := strconv.Atoi(args[1])
+	if err != nil {
+		return errors.New("invalid second DB index")
+	}
+
+	if err := s.SwapDBs(a, b); err != nil {
+		return err
+	}
+	return writeResponse(c, resp.NewString("OK"))
+}
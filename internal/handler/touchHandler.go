@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func touchHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) == 0 {
+		return errors.New("wrong number of arguments for 'touch' command")
+	}
+
+	count := 0
+	for _, key := range args {
+		if s.GetDB(c.DB).Exists(key) {
+			count++
+		}
+	}
+
+	return writeResponse(c, resp.NewInt(int64(count)))
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+)
+
+func ttlHandler(c *client.Client, s *state.AppState, args []string) error {
+	return ttlGeneric(c, s, "ttl", args, false, false)
+}
+
+func pttlHandler(c *client.Client, s *state.AppState, args []string) error {
+	return ttlGeneric(c, s, "pttl", args, true, false)
+}
+
+func expiretimeHandler(c *client.Client, s *state.AppState, args []string) error {
+	return ttlGeneric(c, s, "expiretime", args, false, true)
+}
+
+func pexpiretimeHandler(c *client.Client, s *state.AppState, args []string) error {
+	return ttlGeneric(c, s, "pexpiretime", args, true, true)
+}
+
+// ttlGeneric replies -2 for a missing key and -1 for a key without expiry,
+// otherwise the remaining time to live, or with absolute the expiry as a
+// unix time.
+func ttlGeneric(c *client.Client, s *state.AppState, name string, args []string, millis, absolute bool) error {
+	if len(args) != 1 {
+		return errors.New("wrong number of arguments for '" + name + "' command")
+	}
+
+	expireAt, ok := s.GetDB(c.DB).GetExpire(args[0])
+	if !ok {
+		return writeResponse(c, resp.NewInt(-2))
+	}
+	if expireAt == nil {
+		return writeResponse(c, resp.NewInt(-1))
+	}
+
+	if absolute {
+		if millis {
+			return writeResponse(c, resp.NewInt(*expireAt))
+		}
+		return writeResponse(c, resp.NewInt(*expireAt/1000))
+	}
+
+	ttl := max(*expireAt-time.Now().UnixMilli(), 0)
+	if millis {
+		return writeResponse(c, resp.NewInt(ttl))
+	}
+	return writeResponse(c, resp.NewInt((ttl+500)/1000))
+}
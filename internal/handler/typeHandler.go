@@ -14,6 +14,6 @@ func typeHandler(c *client.Client, s *state.AppState, args []string) error {
 	}
 
 	key := args[0]
-	valType := s.GetStore().Type(key)
+	valType := s.GetDB(c.DB).Type(key)
 	return writeResponse(c, resp.NewString(valType))
 }
@@ -15,18 +15,15 @@ func unsubscribeHandler(c *client.Client, s *state.AppState, args []string) erro
 
 	unsubMsg := "unsubscribe"
 	for _, channel := range args {
-		sub := s.UnsubChannel(c.Conn.ID, channel)
-		chanCount := 0
-		if sub != nil {
-			chanCount = len(sub.Channels)
-		}
+		count := s.UnsubChannel(c.Conn.ID, channel)
+		c.SubMode = s.HasSubscriptions(c.Conn.ID)
 
 		c.Conn.WriteResp(
 			resp.NewArray(
 				[]resp.RESPValue{
 					resp.NewBulkString(&unsubMsg),
 					resp.NewBulkString(&channel),
-					resp.NewInt(int64(chanCount)),
+					resp.NewInt(int64(count)),
 				},
 			),
 		)
@@ -13,7 +13,7 @@ func unwatchHandler(c *client.Client, s *state.AppState, args []string) error {
 		return errors.New("wrong number of arguments for 'unwatch' command")
 	}
 
-	s.GetStore().Unwatch(c.Conn.ID)
+	s.GetDB(c.DB).Unwatch(c.Conn.ID)
 
 	return writeResponse(c, resp.NewString("OK"))
 }
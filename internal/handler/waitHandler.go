@@ -8,9 +8,9 @@ import (
 	"time"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
-	"github.com/0x222fe/codecrafters-redis-go/internal/utils/resputil"
 	"github.com/google/uuid"
 )
 
@@ -41,8 +41,6 @@ func waitHandler(c *client.Client, s *state.AppState, args []string) error {
 	ctx, cancel := context.WithTimeout(c.Ctx, time.Duration(timeoutMillis)*time.Millisecond)
 	defer cancel()
 
-	command := resputil.BulkStringsToRESPArray([]string{"REPLCONF", "GETACK", "*"})
-
 	replicas := s.GetReplicas()
 	acked, jobs := make(map[uuid.UUID]struct{}, len(replicas)), make(map[uuid.UUID]struct{}, len(replicas))
 
@@ -77,8 +75,8 @@ outter:
 		case id := <-doneChan:
 			delete(jobs, id)
 		case <-ticker.C:
-			replicas := s.GetReplicas()
-			for _, r := range replicas {
+			var pending []*state.Replica
+			for _, r := range s.GetReplicas() {
 				if _, ok := acked[r.Conn.ID]; ok {
 					continue
 				}
@@ -86,15 +84,19 @@ outter:
 				if _, ok := jobs[r.Conn.ID]; ok {
 					continue
 				}
+				pending = append(pending, r)
+			}
+			if len(pending) == 0 {
+				continue
+			}
 
-				_, err := r.Conn.Write(command.Bytes())
-				if err != nil {
-					fmt.Printf("Error writing to replica %s: %v\n", r.Conn.ID, err)
-					continue
-				}
-
-				go getRepOffsetUpdate(ctx, c, s, r, syncedChan, doneChan)
+			// INFO: GETACK goes down the replication stream like any other
+			// command, so the replicas count it in their offset just as the
+			// master and its backlog do.
+			s.PropagateToReplicas(command.Command{Name: command.REPLCONF, Args: []string{"GETACK", "*"}})
 
+			for _, r := range pending {
+				go getRepOffsetUpdate(ctx, r, masterOffset, syncedChan, doneChan)
 				jobs[r.Conn.ID] = struct{}{}
 			}
 		}
@@ -104,16 +106,14 @@ outter:
 	return writeResponse(c, resp.NewInt(int64(ackCount)))
 }
 
-func getRepOffsetUpdate(ctx context.Context, c *client.Client, s *state.AppState, rep *state.Replica, syncedChan chan uuid.UUID, doneChan chan uuid.UUID) {
+// getRepOffsetUpdate waits for rep to acknowledge an offset and reports it
+// as synced once that covers masterOffset, the master offset when WAIT was
+// called.
+func getRepOffsetUpdate(ctx context.Context, rep *state.Replica, masterOffset int, syncedChan chan uuid.UUID, doneChan chan uuid.UUID) {
 	defer func() { doneChan <- rep.Conn.ID }()
 
 	select {
 	case count := <-rep.OffsetChan:
-		masterOffset := 0
-		s.ReadState(func(st state.ReplicaState) {
-			masterOffset = st.ReplicationOffset
-		})
-
 		if count >= masterOffset {
 			syncedChan <- rep.Conn.ID
 		}
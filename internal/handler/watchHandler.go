@@ -14,7 +14,7 @@ func watchHandler(c *client.Client, s *state.AppState, args []string) error {
 	}
 
 	keys := args
-	s.GetStore().Watch(keys, c.Conn.ID)
+	s.GetDB(c.DB).Watch(keys, c.Conn.ID)
 
 	return writeResponse(c, resp.NewString("OK"))
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
 	"github.com/0x222fe/codecrafters-redis-go/internal/store"
@@ -20,10 +21,10 @@ func xaddHandler(c *client.Client, s *state.AppState, args []string) error {
 	}
 
 	var stream *store.RedisStream
-	v, t, ok := s.GetStore().Get(key)
+	v, t, ok := s.GetDB(c.DB).Get(key)
 	if !ok {
 		stream = store.NewStream(key)
-		s.GetStore().Set(key, stream, store.Stream, nil)
+		s.GetDB(c.DB).Set(key, stream, store.Stream, nil)
 	} else {
 		var parseOk bool
 		stream, parseOk = v.(*store.RedisStream)
@@ -41,7 +42,8 @@ func xaddHandler(c *client.Client, s *state.AppState, args []string) error {
 		return err
 	}
 
-	s.GetStore().IterateStreamInsertHandlers(key, entry)
+	s.GetDB(c.DB).IterateStreamInsertHandlers(key, entry)
+	s.NotifyKeyspaceEvent(config.NotifyStream, "xadd", key, c.DB)
 
 	entryID := entry.ID.String()
 	res := resp.NewBulkString(&entryID)
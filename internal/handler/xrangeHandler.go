@@ -33,7 +33,7 @@ func xrangeHandler(c *client.Client, s *state.AppState, args []string) error {
 		end = e.RadixKey()
 	}
 
-	v, ok := s.GetStore().GetExact(key, store.Stream)
+	v, ok := s.GetDB(c.DB).GetExact(key, store.Stream)
 	stream, parseOk := v.(*store.RedisStream)
 	if !ok || !parseOk {
 		return errors.New("key is not a stream")
@@ -38,7 +38,7 @@ func xreadHandler(c *client.Client, s *state.AppState, args []string) error {
 	keys, idStrs := args[1:1+count], args[1+count:]
 	streams := make([]*store.RedisStream, 0, count)
 	for _, key := range keys {
-		v, _, has := s.GetStore().Get(key)
+		v, _, has := s.GetDB(c.DB).Get(key)
 		stream, parseOk := v.(*store.RedisStream)
 		if has && !parseOk {
 			return store.ERRWrongType
@@ -84,7 +84,7 @@ func xreadHandler(c *client.Client, s *state.AppState, args []string) error {
 
 		defer func() {
 			for _, key := range keys {
-				s.GetStore().UnregisterStreamInsertHandler(key, c.Conn.ID)
+				s.GetDB(c.DB).UnregisterStreamInsertHandler(key, c.Conn.ID)
 			}
 		}()
 
@@ -98,7 +98,7 @@ func xreadHandler(c *client.Client, s *state.AppState, args []string) error {
 
 		for _, key := range keys {
 			localKey := key
-			s.GetStore().RegisterStreamInsertHandler(key, c.Conn.ID, func(entry *store.StreamEntry) {
+			s.GetDB(c.DB).RegisterStreamInsertHandler(key, c.Conn.ID, func(entry *store.StreamEntry) {
 				doneCh <- streamEntry{streamKey: localKey, entry: entry}
 			})
 		}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
 	"github.com/0x222fe/codecrafters-redis-go/internal/store"
@@ -36,7 +37,13 @@ func zaddHandler(c *client.Client, s *state.AppState, args []string) error {
 		})
 	}
 
-	count := s.GetStore().AddToSortedSet(key, members)
+	count, changed, err := s.GetDB(c.DB).AddToSortedSet(key, members)
+	if err != nil {
+		return err
+	}
+	if changed {
+		s.NotifyKeyspaceEvent(config.NotifyZSet, "zadd", key, c.DB)
+	}
 
 	res := resp.NewInt(int64(count))
 
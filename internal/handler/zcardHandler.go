@@ -15,7 +15,10 @@ func zcardHandler(c *client.Client, s *state.AppState, args []string) error {
 
 	key := args[0]
 
-	count := s.GetStore().CountSortedSetMembers(key)
+	count, err := s.GetDB(c.DB).CountSortedSetMembers(key)
+	if err != nil {
+		return err
+	}
 
 	var res = resp.NewInt(int64(count))
 
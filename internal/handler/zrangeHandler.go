@@ -25,7 +25,10 @@ func zrangeHandler(c *client.Client, s *state.AppState, args []string) error {
 		return errors.New("ZRANGE end argument must be an integer")
 	}
 
-	members := s.GetStore().ListSortedSetMembersByRank(key, start, end)
+	members, err := s.GetDB(c.DB).ListSortedSetMembersByRank(key, start, end)
+	if err != nil {
+		return err
+	}
 
 	var res = resputil.BulkStringsToRESPArray(members)
 
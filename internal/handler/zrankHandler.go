@@ -15,7 +15,10 @@ func zrankHandler(c *client.Client, s *state.AppState, args []string) error {
 
 	key, member := args[0], args[1]
 
-	rank, ok := s.GetStore().QuerySortedSetRank(key, member)
+	rank, ok, err := s.GetDB(c.DB).QuerySortedSetRank(key, member)
+	if err != nil {
+		return err
+	}
 
 	var res resp.RESPValue
 	if !ok {
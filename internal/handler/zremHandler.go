@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
 	"github.com/0x222fe/codecrafters-redis-go/internal/state"
 	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
 )
@@ -15,7 +16,17 @@ func zremHandler(c *client.Client, s *state.AppState, args []string) error {
 
 	key, member := args[0], args[1]
 
-	ok := s.GetStore().RemoveSortedSetMember(key, member)
+	st := s.GetDB(c.DB)
+	ok, err := st.RemoveSortedSetMember(key, member)
+	if err != nil {
+		return err
+	}
+	if ok {
+		s.NotifyKeyspaceEvent(config.NotifyZSet, "zrem", key, c.DB)
+		if !st.Exists(key) {
+			s.NotifyKeyspaceEvent(config.NotifyGeneric, "del", key, c.DB)
+		}
+	}
 
 	var res resp.RESPValue
 	if !ok {
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+func zscanHandler(c *client.Client, s *state.AppState, args []string) error {
+	if len(args) < 2 {
+		return errors.New("wrong number of arguments for 'zscan' command")
+	}
+
+	opts, err := parseScanOptions(args[1:], false, false)
+	if err != nil {
+		return err
+	}
+
+	v, t, ok := s.GetDB(c.DB).Get(args[0])
+	if !ok {
+		return writeScanResponse(c, 0, []string{})
+	}
+	z, isZSet := v.(*store.RedisSortedSet)
+	if t != store.ZSet || !isZSet {
+		return store.ERRWrongType
+	}
+
+	members, next := z.Scan(opts.cursor, opts.count)
+
+	res := make([]string, 0, len(members)*2)
+	for _, m := range members {
+		if !opts.matches(m.Member) {
+			continue
+		}
+		res = append(res, m.Member, fmt.Sprintf("%.17g", m.Score))
+	}
+
+	return writeScanResponse(c, next, res)
+}
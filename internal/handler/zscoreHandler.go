@@ -16,7 +16,10 @@ func zscoreHandler(c *client.Client, s *state.AppState, args []string) error {
 
 	key, member := args[0], args[1]
 
-	score, ok := s.GetStore().QuerySortedSetScore(key, member)
+	score, ok, err := s.GetDB(c.DB).QuerySortedSetScore(key, member)
+	if err != nil {
+		return err
+	}
 
 	var res resp.RESPValue
 	if !ok {
@@ -0,0 +1,44 @@
+package rdb
+
+import (
+	"bufio"
+	"hash"
+	"io"
+
+	"github.com/0x222fe/codecrafters-redis-go/pkg/crc64"
+)
+
+type crcWriter struct {
+	writer   *bufio.Writer
+	crc      hash.Hash64
+	compress bool
+}
+
+func newCRCWriter(writer io.Writer, compress bool) *crcWriter {
+	return &crcWriter{
+		writer:   bufio.NewWriter(writer),
+		crc:      crc64.New(),
+		compress: compress,
+	}
+}
+
+func (w *crcWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	if n > 0 {
+		w.crc.Write(p[:n])
+	}
+	return n, err
+}
+
+func (w *crcWriter) WriteByte(b byte) error {
+	err := w.writer.WriteByte(b)
+	if err != nil {
+		return err
+	}
+	w.crc.Write([]byte{b})
+	return nil
+}
+
+func (w *crcWriter) Flush() error {
+	return w.writer.Flush()
+}
@@ -0,0 +1,447 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+const (
+	rdbVersion = "0011"
+
+	// INFO: same default as Redis' stream-node-max-entries
+	streamNodeMaxEntries = 100
+
+	streamItemFlagNone       = 0
+	streamItemFlagSameFields = 2
+)
+
+type Options struct {
+	// Compression enables LZF compression of long strings, like Redis'
+	// rdbcompression setting.
+	Compression bool
+	// AOFBase marks the dump as the base file of a multi part AOF.
+	AOFBase bool
+}
+
+// WriteRDBFile dumps the snapshot to a temporary file next to filename and
+// atomically renames it into place once it has been fully synced to disk.
+func WriteRDBFile(filename string, dbs [][]store.SnapshotEntry, opts Options) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "temp-*.rdb")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+
+	ok := false
+	defer func() {
+		if !ok {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := EncodeRDB(tmp, dbs, opts); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("rename %s: %w", tmp.Name(), err)
+	}
+
+	ok = true
+	return nil
+}
+
+// EncodeRDB writes an RDB file holding dbs, indexed by database number.
+func EncodeRDB(w io.Writer, dbs [][]store.SnapshotEntry, opts Options) error {
+	writer := newCRCWriter(w, opts.Compression)
+
+	if err := writeHeader(writer); err != nil {
+		return fmt.Errorf("header writing error: %v", err)
+	}
+
+	if err := writeMeta(writer, opts); err != nil {
+		return fmt.Errorf("metadata writing error: %v", err)
+	}
+
+	for idx, entries := range dbs {
+		if len(entries) == 0 {
+			continue
+		}
+		if err := writeDatabase(writer, idx, entries); err != nil {
+			return fmt.Errorf("database writing error: %v", err)
+		}
+	}
+
+	if err := writeEnd(writer); err != nil {
+		return fmt.Errorf("end writing error: %v", err)
+	}
+
+	return writer.Flush()
+}
+
+func writeHeader(writer *crcWriter) error {
+	_, err := writer.Write([]byte("REDIS" + rdbVersion))
+	return err
+}
+
+func writeMeta(writer *crcWriter, opts Options) error {
+	aofBase := "0"
+	if opts.AOFBase {
+		aofBase = "1"
+	}
+
+	meta := [][2]string{
+		{"redis-ver", "7.2.0"},
+		{"redis-bits", strconv.Itoa(strconv.IntSize)},
+		{"ctime", strconv.FormatInt(time.Now().Unix(), 10)},
+		{"used-mem", "0"},
+		{"aof-base", aofBase},
+	}
+
+	for _, kv := range meta {
+		if err := writer.WriteByte(metaFlag); err != nil {
+			return err
+		}
+		if err := writeEncodedString(writer, kv[0]); err != nil {
+			return err
+		}
+		if err := writeEncodedString(writer, kv[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeDatabase(writer *crcWriter, idx int, entries []store.SnapshotEntry) error {
+	if err := writer.WriteByte(dbFlag); err != nil {
+		return err
+	}
+	if err := writeEncodedSize(writer, uint64(idx)); err != nil {
+		return err
+	}
+
+	expirySize := 0
+	for _, e := range entries {
+		if e.ExpireAt != nil {
+			expirySize++
+		}
+	}
+
+	if err := writer.WriteByte(tableFlag); err != nil {
+		return err
+	}
+	if err := writeEncodedSize(writer, uint64(len(entries))); err != nil {
+		return err
+	}
+	if err := writeEncodedSize(writer, uint64(expirySize)); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if err := writeKeyValue(writer, e); err != nil {
+			return fmt.Errorf("error writing key '%s': %v", e.Key, err)
+		}
+	}
+	return nil
+}
+
+func writeKeyValue(writer *crcWriter, e store.SnapshotEntry) error {
+	if e.ExpireAt != nil {
+		if err := writer.WriteByte(msExpFlag); err != nil {
+			return err
+		}
+		if _, err := writer.Write(binary.LittleEndian.AppendUint64(nil, uint64(*e.ExpireAt))); err != nil {
+			return err
+		}
+	}
+
+	switch e.Type {
+	case store.String:
+		v, ok := e.Value.([]byte)
+		if !ok {
+			break
+		}
+		return writeTypedValue(writer, typeString, e.Key, func() error {
+			return writeEncodedString(writer, string(v))
+		})
+	case store.List, store.Set:
+		v, ok := e.Value.([]string)
+		if !ok {
+			break
+		}
+		typeByte := byte(typeList)
+		if e.Type == store.Set {
+			typeByte = typeSet
+		}
+		return writeTypedValue(writer, typeByte, e.Key, func() error {
+			return writeStrings(writer, v)
+		})
+	case store.Hash:
+		v, ok := e.Value.(map[string]string)
+		if !ok {
+			break
+		}
+		return writeTypedValue(writer, typeHash, e.Key, func() error {
+			return writeHash(writer, v)
+		})
+	case store.ZSet:
+		v, ok := e.Value.([]store.SortedSetMember)
+		if !ok {
+			break
+		}
+		return writeTypedValue(writer, typeZSet2, e.Key, func() error {
+			return writeZSet(writer, v)
+		})
+	case store.Stream:
+		v, ok := e.Value.([]*store.StreamEntry)
+		if !ok {
+			break
+		}
+		return writeTypedValue(writer, typeStreamListpacks3, e.Key, func() error {
+			return writeStream(writer, v)
+		})
+	}
+
+	return fmt.Errorf("unsupported value %T of type %s", e.Value, e.Type)
+}
+
+func writeTypedValue(writer *crcWriter, typeByte byte, key string, writeVal func() error) error {
+	if err := writer.WriteByte(typeByte); err != nil {
+		return err
+	}
+	if err := writeEncodedString(writer, key); err != nil {
+		return err
+	}
+	return writeVal()
+}
+
+func writeStrings(writer *crcWriter, items []string) error {
+	if err := writeEncodedSize(writer, uint64(len(items))); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := writeEncodedString(writer, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeHash(writer *crcWriter, fields map[string]string) error {
+	if err := writeEncodedSize(writer, uint64(len(fields))); err != nil {
+		return err
+	}
+	for field, val := range fields {
+		if err := writeEncodedString(writer, field); err != nil {
+			return err
+		}
+		if err := writeEncodedString(writer, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeZSet(writer *crcWriter, members []store.SortedSetMember) error {
+	if err := writeEncodedSize(writer, uint64(len(members))); err != nil {
+		return err
+	}
+	for _, m := range members {
+		if err := writeEncodedString(writer, m.Member); err != nil {
+			return err
+		}
+		if _, err := writer.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(m.Score))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeStream(writer *crcWriter, entries []*store.StreamEntry) error {
+	nodes := make([][]*store.StreamEntry, 0, len(entries)/streamNodeMaxEntries+1)
+	for chunk := range slices.Chunk(entries, streamNodeMaxEntries) {
+		nodes = append(nodes, chunk)
+	}
+
+	if err := writeEncodedSize(writer, uint64(len(nodes))); err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if err := writeRawString(writer, node[0].ID.RadixKey()); err != nil {
+			return err
+		}
+		if err := writeRawString(writer, encodeStreamListpack(node)); err != nil {
+			return err
+		}
+	}
+
+	var first, last store.StreamEntryID
+	if len(entries) > 0 {
+		first, last = entries[0].ID, entries[len(entries)-1].ID
+	}
+
+	meta := []uint64{
+		uint64(len(entries)),  // length
+		last.Millis, last.Seq, // last id
+		first.Millis, first.Seq, // first id
+		0, 0, // max deleted entry id
+		uint64(len(entries)), // entries added
+		0,                    // consumer groups
+	}
+	for _, v := range meta {
+		if err := writeEncodedSize(writer, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeStreamListpack lays out a stream node the way Redis does: a master
+// entry holding the field names of the first entry, followed by every entry
+// with its ID stored as a delta from the master ID. Entries sharing the
+// master fields only store their values.
+func encodeStreamListpack(entries []*store.StreamEntry) []byte {
+	lp := newListpackBuilder()
+
+	master := entries[0]
+	masterFields := sortedFieldNames(master.Fields)
+
+	lp.appendInt(int64(len(entries)))
+	lp.appendInt(0) // deleted entries
+	lp.appendInt(int64(len(masterFields)))
+	for _, f := range masterFields {
+		lp.appendString(f)
+	}
+	lp.appendInt(0) // master entry terminator
+
+	for _, e := range entries {
+		fields := sortedFieldNames(e.Fields)
+		sameFields := slices.Equal(fields, masterFields)
+
+		flags := streamItemFlagNone
+		if sameFields {
+			flags = streamItemFlagSameFields
+		}
+
+		lp.appendInt(int64(flags))
+		lp.appendInt(int64(e.ID.Millis - master.ID.Millis))
+		lp.appendInt(int64(e.ID.Seq - master.ID.Seq))
+
+		if sameFields {
+			for _, f := range fields {
+				lp.appendString(e.Fields[f])
+			}
+			lp.appendInt(int64(len(fields) + 3))
+			continue
+		}
+
+		lp.appendInt(int64(len(fields)))
+		for _, f := range fields {
+			lp.appendString(f)
+			lp.appendString(e.Fields[f])
+		}
+		lp.appendInt(int64(2*len(fields) + 4))
+	}
+
+	return lp.bytes()
+}
+
+func sortedFieldNames(fields map[string]string) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func writeEnd(writer *crcWriter) error {
+	if err := writer.WriteByte(endFlag); err != nil {
+		return err
+	}
+
+	checksum := writer.crc.Sum64()
+	_, err := writer.writer.Write(binary.LittleEndian.AppendUint64(nil, checksum))
+	return err
+}
+
+func writeEncodedSize(writer *crcWriter, size uint64) error {
+	var buf []byte
+	switch {
+	case size < 1<<6:
+		buf = []byte{byte(size)}
+	case size < 1<<14:
+		buf = []byte{0b_0100_0000 | byte(size>>8), byte(size)}
+	case size <= math.MaxUint32:
+		buf = binary.BigEndian.AppendUint32([]byte{size32Flag}, uint32(size))
+	default:
+		buf = binary.BigEndian.AppendUint64([]byte{size64Flag}, size)
+	}
+
+	_, err := writer.Write(buf)
+	return err
+}
+
+// writeEncodedString stores short canonical integers in their compact
+// integer encoding and everything else as a length-prefixed raw string.
+func writeEncodedString(writer *crcWriter, s string) error {
+	if len(s) <= 11 {
+		if n, err := strconv.ParseInt(s, 10, 32); err == nil && strconv.FormatInt(n, 10) == s {
+			var buf []byte
+			switch {
+			case n >= math.MinInt8 && n <= math.MaxInt8:
+				buf = []byte{int8Enc, byte(n)}
+			case n >= math.MinInt16 && n <= math.MaxInt16:
+				buf = binary.LittleEndian.AppendUint16([]byte{int16Enc}, uint16(n))
+			default:
+				buf = binary.LittleEndian.AppendUint32([]byte{int32Enc}, uint32(n))
+			}
+			_, err := writer.Write(buf)
+			return err
+		}
+	}
+
+	return writeRawString(writer, []byte(s))
+}
+
+func writeRawString(writer *crcWriter, b []byte) error {
+	if writer.compress {
+		if compressed := lzfCompress(b); compressed != nil {
+			return writeLZFString(writer, compressed, len(b))
+		}
+	}
+
+	if err := writeEncodedSize(writer, uint64(len(b))); err != nil {
+		return err
+	}
+	_, err := writer.Write(b)
+	return err
+}
+
+func writeLZFString(writer *crcWriter, compressed []byte, originalLen int) error {
+	if err := writer.WriteByte(lzfEnc); err != nil {
+		return err
+	}
+	if err := writeEncodedSize(writer, uint64(len(compressed))); err != nil {
+		return err
+	}
+	if err := writeEncodedSize(writer, uint64(originalLen)); err != nil {
+		return err
+	}
+	_, err := writer.Write(compressed)
+	return err
+}
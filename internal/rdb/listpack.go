@@ -0,0 +1,200 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+const (
+	listpackHeaderSize = 6
+	listpackEnd        = 0xFF
+)
+
+type listpackBuilder struct {
+	buf   []byte
+	count int
+}
+
+func newListpackBuilder() *listpackBuilder {
+	return &listpackBuilder{
+		buf: make([]byte, listpackHeaderSize, 64),
+	}
+}
+
+func (lp *listpackBuilder) appendInt(v int64) {
+	start := len(lp.buf)
+
+	switch {
+	case v >= 0 && v <= 127:
+		lp.buf = append(lp.buf, byte(v))
+	case v >= -4096 && v <= 4095:
+		uv := uint64(v) & 0x1FFF
+		lp.buf = append(lp.buf, 0xC0|byte(uv>>8), byte(uv))
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		lp.buf = append(lp.buf, 0xF1)
+		lp.buf = binary.LittleEndian.AppendUint16(lp.buf, uint16(v))
+	case v >= -(1<<23) && v <= 1<<23-1:
+		uv := uint32(v)
+		lp.buf = append(lp.buf, 0xF2, byte(uv), byte(uv>>8), byte(uv>>16))
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		lp.buf = append(lp.buf, 0xF3)
+		lp.buf = binary.LittleEndian.AppendUint32(lp.buf, uint32(v))
+	default:
+		lp.buf = append(lp.buf, 0xF4)
+		lp.buf = binary.LittleEndian.AppendUint64(lp.buf, uint64(v))
+	}
+
+	lp.appendBacklen(len(lp.buf) - start)
+}
+
+func (lp *listpackBuilder) appendString(s string) {
+	start := len(lp.buf)
+	n := len(s)
+
+	switch {
+	case n < 64:
+		lp.buf = append(lp.buf, 0x80|byte(n))
+	case n < 4096:
+		lp.buf = append(lp.buf, 0xE0|byte(n>>8), byte(n))
+	default:
+		lp.buf = append(lp.buf, 0xF0)
+		lp.buf = binary.LittleEndian.AppendUint32(lp.buf, uint32(n))
+	}
+	lp.buf = append(lp.buf, s...)
+
+	lp.appendBacklen(len(lp.buf) - start)
+}
+
+// appendBacklen stores the size of the preceding element so a listpack can be
+// walked backwards. The value is split in 7-bit groups, most significant
+// first, with the high bit set on every group but the first one.
+func (lp *listpackBuilder) appendBacklen(l int) {
+	switch {
+	case l <= 127:
+		lp.buf = append(lp.buf, byte(l))
+	case l < 16383:
+		lp.buf = append(lp.buf, byte(l>>7), byte(l&127)|128)
+	case l < 2097151:
+		lp.buf = append(lp.buf, byte(l>>14), byte((l>>7)&127)|128, byte(l&127)|128)
+	case l < 268435455:
+		lp.buf = append(lp.buf, byte(l>>21), byte((l>>14)&127)|128, byte((l>>7)&127)|128, byte(l&127)|128)
+	default:
+		lp.buf = append(lp.buf, byte(l>>28), byte((l>>21)&127)|128, byte((l>>14)&127)|128, byte((l>>7)&127)|128, byte(l&127)|128)
+	}
+	lp.count++
+}
+
+func (lp *listpackBuilder) bytes() []byte {
+	buf := append(lp.buf, listpackEnd)
+
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(buf)))
+
+	count := lp.count
+	if count > math.MaxUint16 {
+		// INFO: 65535 means the element count is unknown and must be computed by walking the listpack
+		count = math.MaxUint16
+	}
+	binary.LittleEndian.PutUint16(buf[4:6], uint16(count))
+
+	return buf
+}
+
+func decodeListpack(b []byte) ([]string, error) {
+	if len(b) < listpackHeaderSize+1 {
+		return nil, errTruncatedEncoding
+	}
+
+	items := make([]string, 0, binary.LittleEndian.Uint16(b[4:6]))
+	pos := listpackHeaderSize
+
+	for {
+		if pos >= len(b) {
+			return nil, errTruncatedEncoding
+		}
+		if b[pos] == listpackEnd {
+			return items, nil
+		}
+
+		item, size, err := readListpackEntry(b, pos)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		pos += size + backlenSize(size)
+	}
+}
+
+// readListpackEntry decodes the element at pos and returns it together with
+// the size of its encoding and payload, excluding the trailing backlen.
+func readListpackEntry(b []byte, pos int) (string, int, error) {
+	enc := b[pos]
+
+	var strLen, hdr int
+	switch {
+	case enc&0x80 == 0:
+		return strconv.Itoa(int(enc & 0x7F)), 1, nil
+	case enc&0xC0 == 0x80:
+		strLen, hdr = int(enc&0x3F), 1
+	case enc&0xE0 == 0xC0:
+		if pos+2 > len(b) {
+			return "", 0, errTruncatedEncoding
+		}
+		uv := uint16(enc&0x1F)<<8 | uint16(b[pos+1])
+		// INFO: sign-extend the 13-bit two's complement value
+		v := int16(uv<<3) >> 3
+		return strconv.Itoa(int(v)), 2, nil
+	case enc&0xF0 == 0xE0:
+		if pos+2 > len(b) {
+			return "", 0, errTruncatedEncoding
+		}
+		strLen, hdr = int(enc&0x0F)<<8|int(b[pos+1]), 2
+	case enc == 0xF0:
+		if pos+5 > len(b) {
+			return "", 0, errTruncatedEncoding
+		}
+		strLen, hdr = int(binary.LittleEndian.Uint32(b[pos+1:pos+5])), 5
+	case enc >= 0xF1 && enc <= 0xF4:
+		size := [...]int{2, 3, 4, 8}[enc-0xF1]
+		if pos+1+size > len(b) {
+			return "", 0, errTruncatedEncoding
+		}
+		data := b[pos+1 : pos+1+size]
+
+		var v int64
+		switch size {
+		case 2:
+			v = int64(int16(binary.LittleEndian.Uint16(data)))
+		case 3:
+			v = int64(int32(uint32(data[0])<<8|uint32(data[1])<<16|uint32(data[2])<<24) >> 8)
+		case 4:
+			v = int64(int32(binary.LittleEndian.Uint32(data)))
+		default:
+			v = int64(binary.LittleEndian.Uint64(data))
+		}
+		return strconv.FormatInt(v, 10), 1 + size, nil
+	default:
+		return "", 0, fmt.Errorf("invalid listpack encoding 0x%02X", enc)
+	}
+
+	if pos+hdr+strLen > len(b) {
+		return "", 0, errTruncatedEncoding
+	}
+	return string(b[pos+hdr : pos+hdr+strLen]), hdr + strLen, nil
+}
+
+func backlenSize(l int) int {
+	switch {
+	case l <= 127:
+		return 1
+	case l < 16383:
+		return 2
+	case l < 2097151:
+		return 3
+	case l < 268435455:
+		return 4
+	default:
+		return 5
+	}
+}
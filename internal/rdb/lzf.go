@@ -0,0 +1,150 @@
+package rdb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// LZF as implemented by liblzf, which Redis uses for string compression.
+//
+// The compressed stream is a sequence of chunks introduced by a control
+// byte. A control byte below 32 starts a literal run of ctrl+1 bytes. Anything
+// else is a back reference: the top 3 bits hold the match length minus 2
+// (7 meaning an extra length byte follows) and the low 5 bits, together with
+// the next byte, hold the offset minus 1 from the current output position.
+
+const (
+	lzfHashLog = 14
+	lzfMaxLit  = 1 << 5
+	lzfMaxOff  = 1 << 13
+	lzfMaxRef  = (1 << 8) + (1 << 3)
+
+	// INFO: same threshold Redis uses, shorter strings never compress well
+	lzfMinInputLen = 20
+)
+
+var errLZFCorrupted = errors.New("corrupted LZF data")
+
+func lzfDecompress(in []byte, outLen int) ([]byte, error) {
+	out := make([]byte, 0, outLen)
+
+	for ip := 0; ip < len(in); {
+		ctrl := int(in[ip])
+		ip++
+
+		if ctrl < lzfMaxLit {
+			n := ctrl + 1
+			if ip+n > len(in) || len(out)+n > outLen {
+				return nil, errLZFCorrupted
+			}
+			out = append(out, in[ip:ip+n]...)
+			ip += n
+			continue
+		}
+
+		n := ctrl >> 5
+		if n == 7 {
+			if ip >= len(in) {
+				return nil, errLZFCorrupted
+			}
+			n += int(in[ip])
+			ip++
+		}
+		n += 2
+
+		if ip >= len(in) {
+			return nil, errLZFCorrupted
+		}
+		ref := len(out) - (ctrl&0x1F)<<8 - int(in[ip]) - 1
+		ip++
+
+		if ref < 0 || len(out)+n > outLen {
+			return nil, errLZFCorrupted
+		}
+
+		// INFO: the reference may overlap the bytes being produced, copy one by one
+		for i := range n {
+			out = append(out, out[ref+i])
+		}
+	}
+
+	if len(out) != outLen {
+		return nil, fmt.Errorf("LZF decompressed length mismatch: expected %d, got %d", outLen, len(out))
+	}
+	return out, nil
+}
+
+// lzfCompress returns the compressed form of in, or nil when compressing
+// would not save at least 4 bytes.
+func lzfCompress(in []byte) []byte {
+	if len(in) <= lzfMinInputLen {
+		return nil
+	}
+
+	var htab [1 << lzfHashLog]int
+	limit := len(in) - 4
+	out := make([]byte, 0, limit)
+
+	lit, litPos := 0, 0
+	emitLiteral := func(b byte) {
+		if lit == 0 {
+			litPos = len(out)
+			out = append(out, 0)
+		}
+		out = append(out, b)
+		lit++
+		out[litPos] = byte(lit - 1)
+		if lit == lzfMaxLit {
+			lit = 0
+		}
+	}
+
+	ip := 0
+	for ip+2 < len(in) {
+		if len(out) >= limit {
+			return nil
+		}
+
+		h := lzfHash(in[ip], in[ip+1], in[ip+2])
+		// INFO: the table stores positions shifted by one so zero means empty
+		ref := htab[h] - 1
+		htab[h] = ip + 1
+
+		off := ip - ref - 1
+		if ref < 0 || off >= lzfMaxOff ||
+			in[ref] != in[ip] || in[ref+1] != in[ip+1] || in[ref+2] != in[ip+2] {
+			emitLiteral(in[ip])
+			ip++
+			continue
+		}
+
+		n := 3
+		maxLen := min(len(in)-ip, lzfMaxRef)
+		for n < maxLen && in[ref+n] == in[ip+n] {
+			n++
+		}
+
+		lit = 0
+		if n-2 < 7 {
+			out = append(out, byte(off>>8)|byte(n-2)<<5)
+		} else {
+			out = append(out, byte(off>>8)|7<<5, byte(n-2-7))
+		}
+		out = append(out, byte(off))
+		ip += n
+	}
+
+	for ; ip < len(in); ip++ {
+		emitLiteral(in[ip])
+	}
+
+	if len(out) >= limit {
+		return nil
+	}
+	return out
+}
+
+func lzfHash(a, b, c byte) int {
+	v := uint32(a)<<16 | uint32(b)<<8 | uint32(c)
+	return int((v * 2654435761) >> (32 - lzfHashLog))
+}
@@ -0,0 +1,164 @@
+package rdb
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+func TestLZFRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	randomBytes := make([]byte, 4096)
+	r.Read(randomBytes)
+
+	tests := []struct {
+		name         string
+		input        []byte
+		compressible bool
+	}{
+		{"too short", []byte("aaaaaaaaaaaaaaaaaaaa"), false},
+		{"repeated byte", bytes.Repeat([]byte("a"), 1000), true},
+		{"repeated phrase", []byte(strings.Repeat("hello world ", 50)), true},
+		{"long match", bytes.Repeat([]byte("0123456789"), 3000), true},
+		{"random", randomBytes, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed := lzfCompress(tt.input)
+			if (compressed != nil) != tt.compressible {
+				t.Fatalf("lzfCompress() compressible = %v, want %v", compressed != nil, tt.compressible)
+			}
+			if compressed == nil {
+				return
+			}
+			if len(compressed) >= len(tt.input) {
+				t.Errorf("compressed length %d is not smaller than input length %d", len(compressed), len(tt.input))
+			}
+
+			got, err := lzfDecompress(compressed, len(tt.input))
+			if err != nil {
+				t.Fatalf("lzfDecompress() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.input) {
+				t.Errorf("lzfDecompress() round trip mismatch")
+			}
+		})
+	}
+}
+
+func TestLZFDecompressCorrupted(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  []byte
+		outLen int
+	}{
+		{"truncated literal", []byte{0x05, 'a', 'b'}, 6},
+		{"reference before start", []byte{0x00, 'a', 0x20, 0x05}, 4},
+		{"missing offset byte", []byte{0x00, 'a', 0x20}, 4},
+		{"output longer than declared", []byte{0x02, 'a', 'b', 'c'}, 2},
+		{"output shorter than declared", []byte{0x02, 'a', 'b', 'c'}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := lzfDecompress(tt.input, tt.outLen); err == nil {
+				t.Errorf("lzfDecompress() expected error, got nil")
+			}
+		})
+	}
+}
+
+// testdata/lzf.rdb was produced with liblzf and holds a compressed string and
+// a compressed listpack-encoded hash.
+func loadLZFFixture(t *testing.T) *RDB {
+	t.Helper()
+
+	f, err := os.Open("testdata/lzf.rdb")
+	if err != nil {
+		t.Fatalf("open fixture: %v", err)
+	}
+	defer f.Close()
+
+	r, err := ParseRDB(f)
+	if err != nil {
+		t.Fatalf("ParseRDB() error = %v", err)
+	}
+	return r
+}
+
+func TestReadLZFFixture(t *testing.T) {
+	r := loadLZFFixture(t)
+
+	db, ok := r.databases[0]
+	if !ok {
+		t.Fatal("database 0 not found")
+	}
+
+	greeting, ok := db.items["greeting"]
+	if !ok {
+		t.Fatal("key 'greeting' not found")
+	}
+	if want := []byte(strings.Repeat("redis-lzf-fixture ", 8)); !bytes.Equal(greeting.value.([]byte), want) {
+		t.Errorf("greeting = %q, want %q", greeting.value, want)
+	}
+
+	profile, ok := db.items["profile"]
+	if !ok {
+		t.Fatal("key 'profile' not found")
+	}
+	wantProfile := map[string]string{
+		"name":  strings.Repeat("name-value-", 4),
+		"email": strings.Repeat("email-value-", 4),
+		"bio":   strings.Repeat("bio-value-", 4),
+	}
+	if profile.valueType != store.Hash || !reflect.DeepEqual(profile.value, wantProfile) {
+		t.Errorf("profile = %s %v, want %s %v", profile.valueType, profile.value, store.Hash, wantProfile)
+	}
+}
+
+func TestLZFFixtureRoundTrip(t *testing.T) {
+	r := loadLZFFixture(t)
+
+	entries := make([]store.SnapshotEntry, 0)
+	for _, kv := range r.databases[0].items {
+		entries = append(entries, store.SnapshotEntry{
+			Key:      kv.key,
+			Type:     kv.valueType,
+			Value:    kv.value,
+			ExpireAt: kv.expireAt,
+		})
+	}
+
+	for _, compression := range []bool{true, false} {
+		var buf bytes.Buffer
+		if err := EncodeRDB(&buf, [][]store.SnapshotEntry{entries}, Options{Compression: compression}); err != nil {
+			t.Fatalf("EncodeRDB() error = %v", err)
+		}
+
+		if got := bytes.Contains(buf.Bytes(), []byte(strings.Repeat("redis-lzf-fixture ", 8))); got == compression {
+			t.Errorf("compression = %v but raw string present = %v", compression, got)
+		}
+
+		decoded, err := ParseRDB(&buf)
+		if err != nil {
+			t.Fatalf("ParseRDB() error = %v", err)
+		}
+
+		for key, want := range r.databases[0].items {
+			got, ok := decoded.databases[0].items[key]
+			if !ok {
+				t.Errorf("compression = %v: key %q missing after round trip", compression, key)
+				continue
+			}
+			if !reflect.DeepEqual(got.value, want.value) {
+				t.Errorf("compression = %v: key %q = %v, want %v", compression, key, got.value, want.value)
+			}
+		}
+	}
+}
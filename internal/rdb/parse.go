@@ -8,8 +8,6 @@ import (
 	"io"
 	"os"
 	"strconv"
-
-	"github.com/0x222fe/codecrafters-redis-go/internal/store"
 )
 
 func ReadRDBFile(filename string) (*RDB, error) {
@@ -97,98 +95,108 @@ func parseMeta(reader *crcReader, rdb *RDB) error {
 }
 
 func parseDatabase(reader *crcReader, rdb *RDB) error {
+	var db *database
+
 	for {
 		flag, err := reader.Peek(1)
 		if err != nil {
 			return err
 		}
-		if flag[0] == endFlag {
-			return nil
-		}
 
-		if flag[0] != dbFlag {
-			return fmt.Errorf("expected database flag, got 0x%02X", flag[0])
-		}
-
-		_, err = reader.Discard(1)
-		if err != nil {
-			return err
-		}
-
-		idx, err := readEncodedSize(reader)
-		if err != nil {
-			return err
-		}
-
-		_, has := rdb.databases[idx]
-		if has {
-			return fmt.Errorf("database with index %d already exists", idx)
-		}
+		switch flag[0] {
+		case endFlag:
+			return nil
+		case metaFlag:
+			// INFO: AUX fields are allowed anywhere, Redis emits some of them after SELECTDB
+			if err := parseMeta(reader, rdb); err != nil {
+				return err
+			}
+			continue
+		case functionFlag:
+			if _, err := reader.Discard(1); err != nil {
+				return err
+			}
+			if _, err := readEncodedString(reader); err != nil {
+				return err
+			}
+			continue
+		case slotInfoFlag:
+			if _, err := reader.Discard(1); err != nil {
+				return err
+			}
+			for range 3 {
+				if _, err := readEncodedSize(reader); err != nil {
+					return err
+				}
+			}
+			continue
+		case moduleAuxFlag:
+			return errors.New("module aux data is not supported")
+		case dbFlag:
+			if _, err := reader.Discard(1); err != nil {
+				return err
+			}
 
-		flag, err = reader.Peek(1)
-		if err != nil {
-			return err
-		}
+			idx, err := readEncodedSize(reader)
+			if err != nil {
+				return err
+			}
 
-		if flag[0] == dbFlag || flag[0] == endFlag {
-			return nil
-		}
+			if _, has := rdb.databases[idx]; has {
+				return fmt.Errorf("database with index %d already exists", idx)
+			}
 
-		if flag[0] != tableFlag {
-			return fmt.Errorf("expected table flag, got 0x%02X", flag[0])
-		}
-		_, err = reader.Discard(1)
-		if err != nil {
-			return err
-		}
+			db = &database{
+				index: idx,
+				items: make(map[string]*keyValue),
+			}
+			rdb.databases[idx] = db
+			continue
+		case tableFlag:
+			if db == nil {
+				return errors.New("resize db flag found before any database")
+			}
+			if _, err := reader.Discard(1); err != nil {
+				return err
+			}
 
-		hashSize, err := readEncodedSize(reader)
-		if err != nil {
-			return err
-		}
-		expirySize, err := readEncodedSize(reader)
-		if err != nil {
-			return err
+			// INFO: the sizes are only hints used to presize hash tables
+			db.hashTableSize, err = readEncodedSize(reader)
+			if err != nil {
+				return err
+			}
+			db.expiryHashTableSize, err = readEncodedSize(reader)
+			if err != nil {
+				return err
+			}
+			continue
 		}
 
-		db := &database{
-			index:               idx,
-			hashTableSize:       hashSize,
-			expiryHashTableSize: expirySize,
-			items:               make(map[string]*keyValue),
+		if db == nil {
+			return fmt.Errorf("expected database flag, got 0x%02X", flag[0])
 		}
-		rdb.databases[idx] = db
 
-		err = parseKeyValue(reader, db, hashSize, expirySize)
-		if err != nil {
+		if err := parseKeyValue(reader, db); err != nil {
 			return fmt.Errorf("error parsing key-value pairs: %v", err)
 		}
 	}
 }
 
-func parseKeyValue(reader *crcReader, db *database, hashSize, expirySize int) error {
+func parseKeyValue(reader *crcReader, db *database) error {
+	var expiryAt *int64
+	var typeByte byte
 
-	expCount := 0
-	for range hashSize {
-		flag, err := reader.Peek(1)
+opcodes:
+	for {
+		flag, err := reader.ReadByte()
 		if err != nil {
 			return err
 		}
 
-		hashSize--
-
-		var expiryAt *int64
-
-		if flag[0] == msExpFlag || flag[0] == sExpFlag {
-			expCount++
-
-			_, err = reader.Discard(1)
-			if err != nil {
-				return err
-			}
-
+		switch flag {
+		case msExpFlag, sExpFlag:
 			size := 4
-			if flag[0] == msExpFlag {
+			if flag == msExpFlag {
 				size = 8
 			}
 
@@ -199,7 +207,7 @@ func parseKeyValue(reader *crcReader, db *database, hashSize, expirySize int) er
 
 			expAt := int64(0)
 			if size == 4 {
-				expAt = int64(binary.LittleEndian.Uint32(bytes))
+				expAt = int64(binary.LittleEndian.Uint32(bytes)) * 1000
 			} else {
 				expAt = int64(binary.LittleEndian.Uint64(bytes))
 			}
@@ -208,41 +216,36 @@ func parseKeyValue(reader *crcReader, db *database, hashSize, expirySize int) er
 				return fmt.Errorf("invalid expiry time: %d", expAt)
 			}
 			expiryAt = &expAt
+		case idleFlag:
+			if _, err := readEncodedSize(reader); err != nil {
+				return err
+			}
+		case freqFlag:
+			if _, err := reader.ReadByte(); err != nil {
+				return err
+			}
+		default:
+			typeByte = flag
+			break opcodes
 		}
+	}
 
-		typeByte, err := reader.ReadByte()
-		if err != nil {
-			return err
-		}
-
-		key, err := readEncodedString(reader)
-		if err != nil {
-			return err
-		}
-		val, err := readEncodedString(reader)
-		if err != nil {
-			return err
-		}
-
-		valType, err := rdbTypeToValueType(typeByte)
-		if err != nil {
-			return fmt.Errorf("unknown RDB type byte 0x%02X for key '%s': %v", typeByte, key, err)
-		}
-
-		kv := &keyValue{
-			key:       key,
-			value:     val,
-			valueType: valType,
-			expireAt:  expiryAt,
-		}
-
-		db.items[key] = kv
+	key, err := readEncodedString(reader)
+	if err != nil {
+		return err
 	}
 
-	if expCount != expirySize {
-		return fmt.Errorf("expected %d expiry entries, got %d", expirySize, expCount)
+	valType, val, err := readValue(reader, typeByte)
+	if err != nil {
+		return fmt.Errorf("error reading value of key '%s': %v", key, err)
 	}
 
+	db.items[key] = &keyValue{
+		key:       key,
+		value:     val,
+		valueType: valType,
+		expireAt:  expiryAt,
+	}
 	return nil
 }
 
@@ -302,11 +305,22 @@ func readEncodedSize(reader *crcReader) (int, error) {
 
 		return int(int16(firstByte&mask)<<8 | int16(secondByte)), nil
 	case 0b_10:
-		fourBytes := make([]byte, 4)
-		if _, err := io.ReadFull(reader, fourBytes); err != nil {
-			return 0, err
+		switch firstByte {
+		case size32Flag:
+			fourBytes := make([]byte, 4)
+			if _, err := io.ReadFull(reader, fourBytes); err != nil {
+				return 0, err
+			}
+			return int(binary.BigEndian.Uint32(fourBytes)), nil
+		case size64Flag:
+			eightBytes := make([]byte, 8)
+			if _, err := io.ReadFull(reader, eightBytes); err != nil {
+				return 0, err
+			}
+			return int(binary.BigEndian.Uint64(eightBytes)), nil
+		default:
+			return 0, fmt.Errorf("invalid size encoding: first byte 0x%02X", firstByte)
 		}
-		return int(binary.BigEndian.Uint32(fourBytes)), nil
 	case 0b_11:
 		fallthrough
 	default:
@@ -315,24 +329,36 @@ func readEncodedSize(reader *crcReader) (int, error) {
 }
 
 func readEncodedString(reader *crcReader) (string, error) {
-	b, err := reader.ReadByte()
+	flag, err := reader.Peek(1)
 	if err != nil {
 		return "", err
 	}
+	b := flag[0]
 
 	isNumString := b>>6 == 0b_11
-	size := int(b)
 
+	var size int
 	if isNumString {
+		if _, err := reader.Discard(1); err != nil {
+			return "", err
+		}
+
 		switch b {
-		case 0xC0:
+		case int8Enc:
 			size = 1
-		case 0xC1:
+		case int16Enc:
 			size = 2
-		case 0xC2:
+		case int32Enc:
 			size = 4
-		case 0xC3:
-			return "", errors.New("LZF compressed strings not supported")
+		case lzfEnc:
+			return readLZFString(reader)
+		default:
+			return "", fmt.Errorf("invalid string encoding: 0x%02X", b)
+		}
+	} else {
+		size, err = readEncodedSize(reader)
+		if err != nil {
+			return "", err
 		}
 	}
 
@@ -348,34 +374,35 @@ func readEncodedString(reader *crcReader) (string, error) {
 
 	if isNumString {
 		switch b {
-		case 0xC0: //INFO: int8
-			return string(bytes), nil
-		case 0xC1: // INFO: int16 little-endian
-			return strconv.Itoa(int(binary.LittleEndian.Uint16(bytes))), nil
-		case 0xC2: // INFO: int32 little-endian
-			return strconv.Itoa(int(binary.LittleEndian.Uint32(bytes))), nil
-		case 0xC3:
-			return "", errors.New("LZF compressed strings not supported")
+		case int8Enc:
+			return strconv.Itoa(int(int8(bytes[0]))), nil
+		case int16Enc: // INFO: int16 little-endian
+			return strconv.Itoa(int(int16(binary.LittleEndian.Uint16(bytes)))), nil
+		case int32Enc: // INFO: int32 little-endian
+			return strconv.Itoa(int(int32(binary.LittleEndian.Uint32(bytes)))), nil
 		}
 	}
 	return string(bytes), nil
 }
 
-func rdbTypeToValueType(typeByte byte) (store.ValueType, error) {
-	switch typeByte {
-	case 0x00:
-		return store.String, nil
-	case 0x01:
-		return store.List, nil
-	case 0x02:
-		return store.Set, nil
-	case 0x03:
-		return store.ZSet, nil
-	case 0x04:
-		return store.Hash, nil
-	case 0x09:
-		return store.Stream, nil
-	default:
-		return store.None, fmt.Errorf("unknown RDB type byte: 0x%02X", typeByte)
+func readLZFString(reader *crcReader) (string, error) {
+	compressedLen, err := readEncodedSize(reader)
+	if err != nil {
+		return "", err
+	}
+	originalLen, err := readEncodedSize(reader)
+	if err != nil {
+		return "", err
+	}
+
+	compressed := make([]byte, compressedLen)
+	if _, err := io.ReadFull(reader, compressed); err != nil {
+		return "", err
+	}
+
+	data, err := lzfDecompress(compressed, originalLen)
+	if err != nil {
+		return "", err
 	}
+	return string(data), nil
 }
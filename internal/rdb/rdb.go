@@ -1,6 +1,8 @@
 package rdb
 
 import (
+	"fmt"
+
 	"github.com/0x222fe/codecrafters-redis-go/internal/store"
 )
 
@@ -17,32 +19,125 @@ type database struct {
 	items               map[string]*keyValue
 }
 
+// keyValue.value holds the decoded value in the same shape as
+// store.SnapshotEntry.Value.
 type keyValue struct {
 	key       string
-	value     string
+	value     any
 	valueType store.ValueType
 	expireAt  *int64
 }
 
 const (
-	metaFlag  = 0xFA
-	tableFlag = 0xFB
-	msExpFlag = 0xFC
-	sExpFlag  = 0xFD
-	dbFlag    = 0xFE
-	endFlag   = 0xFF
+	slotInfoFlag  = 0xF4
+	functionFlag  = 0xF5
+	moduleAuxFlag = 0xF7
+	idleFlag      = 0xF8
+	freqFlag      = 0xF9
+	metaFlag      = 0xFA
+	tableFlag     = 0xFB
+	msExpFlag     = 0xFC
+	sExpFlag      = 0xFD
+	dbFlag        = 0xFE
+	endFlag       = 0xFF
+)
+
+const (
+	size32Flag = 0x80
+	size64Flag = 0x81
+
+	int8Enc  = 0xC0
+	int16Enc = 0xC1
+	int32Enc = 0xC2
+	lzfEnc   = 0xC3
+)
+
+const (
+	typeString           = 0x00
+	typeList             = 0x01
+	typeSet              = 0x02
+	typeZSet             = 0x03
+	typeHash             = 0x04
+	typeZSet2            = 0x05
+	typeModule           = 0x06
+	typeModule2          = 0x07
+	typeHashZipmap       = 0x09
+	typeListZiplist      = 0x0A
+	typeSetIntset        = 0x0B
+	typeZSetZiplist      = 0x0C
+	typeHashZiplist      = 0x0D
+	typeListQuicklist    = 0x0E
+	typeStreamListpacks  = 0x0F
+	typeHashListpack     = 0x10
+	typeZSetListpack     = 0x11
+	typeListQuicklist2   = 0x12
+	typeStreamListpacks2 = 0x13
+	typeSetListpack      = 0x14
+	typeStreamListpacks3 = 0x15
 )
 
-func (rdb *RDB) MapToStore() *store.Store {
-	s := store.NewStore()
+// MapToStores loads the file into n stores, one per database index.
+// Databases past n are skipped.
+func (rdb *RDB) MapToStores(n int) []*store.Store {
+	stores := make([]*store.Store, n)
+	for i := range stores {
+		stores[i] = store.NewStore()
+	}
 	if rdb == nil {
-		return s
+		return stores
 	}
 
-	for _, db := range rdb.databases {
+	for idx, db := range rdb.databases {
+		if idx < 0 || idx >= n {
+			fmt.Printf("Skipping database %d, only %d databases are configured\n", idx, n)
+			continue
+		}
 		for _, kv := range db.items {
-			s.Set(kv.key, kv.value, kv.valueType, kv.expireAt)
+			if err := loadKeyValue(stores[idx], kv); err != nil {
+				fmt.Printf("Failed to load key '%s': %s\n", kv.key, err.Error())
+			}
+		}
+	}
+	return stores
+}
+
+func loadKeyValue(s *store.Store, kv *keyValue) error {
+	switch v := kv.value.(type) {
+	case []byte:
+		s.SetString(kv.key, string(v), store.SetOptions{ExpireAt: kv.expireAt})
+	case []string:
+		switch kv.valueType {
+		case store.List:
+			list := store.NewList()
+			list.RPush(v...)
+			s.Set(kv.key, list, store.List, kv.expireAt)
+		case store.Set:
+			set := store.NewSet()
+			set.Add(v...)
+			s.Set(kv.key, set, store.Set, kv.expireAt)
+		default:
+			return fmt.Errorf("unexpected %s value", kv.valueType)
+		}
+	case map[string]string:
+		hash := store.NewHash()
+		for field, val := range v {
+			hash.Set(field, val)
+		}
+		s.Set(kv.key, hash, store.Hash, kv.expireAt)
+	case []store.SortedSetMember:
+		zset := store.NewSortedSet()
+		zset.Add(v...)
+		s.Set(kv.key, zset, store.ZSet, kv.expireAt)
+	case []*store.StreamEntry:
+		stream := store.NewStream(kv.key)
+		for _, entry := range v {
+			if _, err := stream.AddEntry(entry.ID.String(), entry.Fields); err != nil {
+				return err
+			}
 		}
+		s.Set(kv.key, stream, store.Stream, kv.expireAt)
+	default:
+		return fmt.Errorf("unsupported value of type %s", kv.valueType)
 	}
-	return s
+	return nil
 }
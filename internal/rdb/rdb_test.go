@@ -0,0 +1,77 @@
+package rdb
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+func roundTripEntries() [][]store.SnapshotEntry {
+	expireAt := time.Now().Add(time.Hour).UnixMilli()
+
+	return [][]store.SnapshotEntry{
+		{
+			{Key: "int", Type: store.String, Value: []byte("-12345")},
+			{Key: "binary", Type: store.String, Value: []byte("a\x00b\r\n\xff")},
+			{Key: "long", Type: store.String, Value: []byte(strings.Repeat("compress me ", 20))},
+			{Key: "empty", Type: store.String, Value: []byte{}},
+			{Key: "expiring", Type: store.String, Value: []byte("v"), ExpireAt: &expireAt},
+			{Key: "list", Type: store.List, Value: []string{"a", "1", "", "a"}},
+			{Key: "set", Type: store.Set, Value: []string{"1", "2", "x"}},
+			{Key: "hash", Type: store.Hash, Value: map[string]string{"f1": "v1", "f2": "100"}},
+			{Key: "zset", Type: store.ZSet, Value: []store.SortedSetMember{{Score: -1.5, Member: "a"}, {Score: 2, Member: "b"}}},
+			{Key: "stream", Type: store.Stream, Value: []*store.StreamEntry{
+				{ID: store.StreamEntryID{Millis: 1, Seq: 0}, Fields: map[string]string{"a": "1"}},
+				{ID: store.StreamEntryID{Millis: 1, Seq: 1}, Fields: map[string]string{"a": "2", "b": "x"}},
+				{ID: store.StreamEntryID{Millis: 5, Seq: 0}, Fields: map[string]string{"c": "3"}},
+			}},
+		},
+		{
+			{Key: "other-db", Type: store.String, Value: []byte("1")},
+		},
+	}
+}
+
+// normalizeEntries sorts what the store does not keep in a fixed order.
+func normalizeEntries(entries []store.SnapshotEntry) {
+	slices.SortFunc(entries, func(a, b store.SnapshotEntry) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	for _, e := range entries {
+		if e.Type == store.Set {
+			slices.Sort(e.Value.([]string))
+		}
+	}
+}
+
+func TestEncodeParseRoundTrip(t *testing.T) {
+	want := roundTripEntries()
+
+	for _, compression := range []bool{true, false} {
+		var buf bytes.Buffer
+		if err := EncodeRDB(&buf, want, Options{Compression: compression}); err != nil {
+			t.Fatalf("EncodeRDB() error = %v", err)
+		}
+
+		r, err := ParseRDB(&buf)
+		if err != nil {
+			t.Fatalf("compression = %v: ParseRDB() error = %v", compression, err)
+		}
+
+		stores := r.MapToStores(len(want))
+		for i, st := range stores {
+			got := st.Snapshot()
+			normalizeEntries(got)
+			normalizeEntries(want[i])
+
+			if !reflect.DeepEqual(got, want[i]) {
+				t.Errorf("compression = %v: db %d = %+v, want %+v", compression, i, got, want[i])
+			}
+		}
+	}
+}
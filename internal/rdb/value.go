@@ -0,0 +1,472 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+const (
+	streamItemFlagDeleted = 1
+
+	plainContainer  = 1
+	packedContainer = 2
+)
+
+// readValue decodes a value of the given RDB type into the same shape
+// store.Snapshot produces, see store.SnapshotEntry.
+func readValue(reader *crcReader, typeByte byte) (store.ValueType, any, error) {
+	switch typeByte {
+	case typeString:
+		v, err := readEncodedString(reader)
+		return store.String, []byte(v), err
+	case typeList:
+		v, err := readStringSlice(reader, 1)
+		return store.List, v, err
+	case typeListZiplist:
+		v, err := readBlob(reader, decodeZiplist)
+		return store.List, v, err
+	case typeListQuicklist:
+		v, err := readQuicklist(reader)
+		return store.List, v, err
+	case typeListQuicklist2:
+		v, err := readQuicklist2(reader)
+		return store.List, v, err
+	case typeSet:
+		v, err := readStringSlice(reader, 1)
+		return store.Set, v, err
+	case typeSetIntset:
+		v, err := readBlob(reader, decodeIntset)
+		return store.Set, v, err
+	case typeSetListpack:
+		v, err := readBlob(reader, decodeListpack)
+		return store.Set, v, err
+	case typeHash:
+		v, err := readStringSlice(reader, 2)
+		if err != nil {
+			return store.Hash, nil, err
+		}
+		return store.Hash, pairsToHash(v), nil
+	case typeHashZipmap:
+		v, err := readBlob(reader, decodeZipmap)
+		if err != nil {
+			return store.Hash, nil, err
+		}
+		return store.Hash, pairsToHash(v), nil
+	case typeHashZiplist, typeHashListpack:
+		decode := decodeZiplist
+		if typeByte == typeHashListpack {
+			decode = decodeListpack
+		}
+		v, err := readBlob(reader, decode)
+		if err != nil {
+			return store.Hash, nil, err
+		}
+		if len(v)%2 != 0 {
+			return store.Hash, nil, errors.New("hash encoding has an odd number of elements")
+		}
+		return store.Hash, pairsToHash(v), nil
+	case typeZSet, typeZSet2:
+		v, err := readZSet(reader, typeByte == typeZSet2)
+		return store.ZSet, v, err
+	case typeZSetZiplist, typeZSetListpack:
+		decode := decodeZiplist
+		if typeByte == typeZSetListpack {
+			decode = decodeListpack
+		}
+		v, err := readBlob(reader, decode)
+		if err != nil {
+			return store.ZSet, nil, err
+		}
+		members, err := pairsToZSet(v)
+		return store.ZSet, members, err
+	case typeStreamListpacks, typeStreamListpacks2, typeStreamListpacks3:
+		v, err := readStream(reader, typeByte)
+		return store.Stream, v, err
+	case typeModule, typeModule2:
+		return store.None, nil, errors.New("module values are not supported")
+	default:
+		return store.None, nil, fmt.Errorf("unknown RDB type byte: 0x%02X", typeByte)
+	}
+}
+
+// readStringSlice reads a length-prefixed sequence of strings, where the
+// length counts groups of perItem strings.
+func readStringSlice(reader *crcReader, perItem int) ([]string, error) {
+	n, err := readEncodedSize(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]string, 0, n*perItem)
+	for range n * perItem {
+		s, err := readEncodedString(reader)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+	}
+	return items, nil
+}
+
+func readBlob(reader *crcReader, decode func([]byte) ([]string, error)) ([]string, error) {
+	blob, err := readEncodedString(reader)
+	if err != nil {
+		return nil, err
+	}
+	return decode([]byte(blob))
+}
+
+func readQuicklist(reader *crcReader) ([]string, error) {
+	n, err := readEncodedSize(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]string, 0)
+	for range n {
+		node, err := readBlob(reader, decodeZiplist)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, node...)
+	}
+	return items, nil
+}
+
+func readQuicklist2(reader *crcReader) ([]string, error) {
+	n, err := readEncodedSize(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]string, 0)
+	for range n {
+		container, err := readEncodedSize(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		switch container {
+		case plainContainer:
+			item, err := readEncodedString(reader)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		case packedContainer:
+			node, err := readBlob(reader, decodeListpack)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, node...)
+		default:
+			return nil, fmt.Errorf("unknown quicklist container type: %d", container)
+		}
+	}
+	return items, nil
+}
+
+func readZSet(reader *crcReader, binaryScore bool) ([]store.SortedSetMember, error) {
+	n, err := readEncodedSize(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]store.SortedSetMember, 0, n)
+	for range n {
+		member, err := readEncodedString(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		var score float64
+		if binaryScore {
+			score, err = readBinaryDouble(reader)
+		} else {
+			score, err = readStringDouble(reader)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, store.SortedSetMember{Score: score, Member: member})
+	}
+	return members, nil
+}
+
+func readBinaryDouble(reader *crcReader) (float64, error) {
+	buf := make([]byte, 8)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(buf)), nil
+}
+
+// readStringDouble reads the legacy score encoding: a one byte length
+// followed by the ASCII representation, with 253/254/255 standing for
+// NaN/+inf/-inf.
+func readStringDouble(reader *crcReader) (float64, error) {
+	l, err := reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	switch l {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+
+	buf := make([]byte, l)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(string(buf), 64)
+}
+
+func readStream(reader *crcReader, typeByte byte) ([]*store.StreamEntry, error) {
+	nodes, err := readEncodedSize(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*store.StreamEntry, 0)
+	for range nodes {
+		masterKey, err := readEncodedString(reader)
+		if err != nil {
+			return nil, err
+		}
+		if len(masterKey) != 16 {
+			return nil, fmt.Errorf("invalid stream node key length: %d", len(masterKey))
+		}
+		master := store.StreamEntryID{
+			Millis: binary.BigEndian.Uint64([]byte(masterKey[:8])),
+			Seq:    binary.BigEndian.Uint64([]byte(masterKey[8:])),
+		}
+
+		elems, err := readBlob(reader, decodeListpack)
+		if err != nil {
+			return nil, err
+		}
+
+		nodeEntries, err := decodeStreamNode(master, elems)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, nodeEntries...)
+	}
+
+	// INFO: length, last id
+	metaCount := 3
+	if typeByte >= typeStreamListpacks2 {
+		// INFO: first id, max deleted id, entries added
+		metaCount += 5
+	}
+	for range metaCount {
+		if _, err := readEncodedSize(reader); err != nil {
+			return nil, err
+		}
+	}
+
+	// INFO: consumer groups are not supported yet, consume and drop them
+	if err := skipStreamConsumerGroups(reader, typeByte); err != nil {
+		return nil, fmt.Errorf("error reading consumer groups: %v", err)
+	}
+
+	return entries, nil
+}
+
+func decodeStreamNode(master store.StreamEntryID, elems []string) ([]*store.StreamEntry, error) {
+	p := &streamNodeParser{elems: elems}
+
+	count := p.int()
+	deleted := p.int()
+	masterFieldCount := p.int()
+	masterFields := p.strings(int(masterFieldCount))
+	p.int() // master entry terminator
+
+	entries := make([]*store.StreamEntry, 0, count)
+	for range count + deleted {
+		flags := p.int()
+		msDiff := p.int()
+		seqDiff := p.int()
+
+		var fields, values []string
+		if flags&streamItemFlagSameFields != 0 {
+			fields = masterFields
+			values = p.strings(len(masterFields))
+		} else {
+			n := int(p.int())
+			fields = make([]string, 0, n)
+			values = make([]string, 0, n)
+			for range n {
+				fields = append(fields, p.string())
+				values = append(values, p.string())
+			}
+		}
+		p.int() // lp-count
+
+		if p.err != nil {
+			return nil, p.err
+		}
+
+		if flags&streamItemFlagDeleted != 0 {
+			continue
+		}
+
+		entry := &store.StreamEntry{
+			ID: store.StreamEntryID{
+				Millis: master.Millis + uint64(msDiff),
+				Seq:    master.Seq + uint64(seqDiff),
+			},
+			Fields: make(map[string]string, len(fields)),
+		}
+		for i, f := range fields {
+			entry.Fields[f] = values[i]
+		}
+		entries = append(entries, entry)
+	}
+
+	if p.err != nil {
+		return nil, p.err
+	}
+	return entries, nil
+}
+
+type streamNodeParser struct {
+	elems []string
+	pos   int
+	err   error
+}
+
+func (p *streamNodeParser) string() string {
+	if p.err != nil {
+		return ""
+	}
+	if p.pos >= len(p.elems) {
+		p.err = errors.New("truncated stream listpack")
+		return ""
+	}
+	s := p.elems[p.pos]
+	p.pos++
+	return s
+}
+
+func (p *streamNodeParser) strings(n int) []string {
+	items := make([]string, 0, n)
+	for range n {
+		items = append(items, p.string())
+	}
+	return items
+}
+
+func (p *streamNodeParser) int() int64 {
+	s := p.string()
+	if p.err != nil {
+		return 0
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		p.err = fmt.Errorf("invalid integer in stream listpack: %q", s)
+	}
+	return n
+}
+
+func skipStreamConsumerGroups(reader *crcReader, typeByte byte) error {
+	groups, err := readEncodedSize(reader)
+	if err != nil {
+		return err
+	}
+
+	for range groups {
+		if _, err := readEncodedString(reader); err != nil {
+			return err
+		}
+
+		// INFO: last delivered id, plus entries read since v2
+		sizes := 2
+		if typeByte >= typeStreamListpacks2 {
+			sizes++
+		}
+		for range sizes {
+			if _, err := readEncodedSize(reader); err != nil {
+				return err
+			}
+		}
+
+		pending, err := readEncodedSize(reader)
+		if err != nil {
+			return err
+		}
+		for range pending {
+			// INFO: raw 16 byte id, 8 byte delivery time
+			if _, err := reader.Discard(16 + 8); err != nil {
+				return err
+			}
+			if _, err := readEncodedSize(reader); err != nil {
+				return err
+			}
+		}
+
+		consumers, err := readEncodedSize(reader)
+		if err != nil {
+			return err
+		}
+		for range consumers {
+			if _, err := readEncodedString(reader); err != nil {
+				return err
+			}
+
+			// INFO: seen time, plus active time since v3
+			times := 8
+			if typeByte >= typeStreamListpacks3 {
+				times += 8
+			}
+			if _, err := reader.Discard(times); err != nil {
+				return err
+			}
+
+			consumerPending, err := readEncodedSize(reader)
+			if err != nil {
+				return err
+			}
+			if _, err := reader.Discard(16 * consumerPending); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func pairsToHash(pairs []string) map[string]string {
+	h := make(map[string]string, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		h[pairs[i]] = pairs[i+1]
+	}
+	return h
+}
+
+func pairsToZSet(pairs []string) ([]store.SortedSetMember, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("sorted set encoding has an odd number of elements")
+	}
+
+	members := make([]store.SortedSetMember, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		score, err := strconv.ParseFloat(pairs[i+1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sorted set score %q", pairs[i+1])
+		}
+		members = append(members, store.SortedSetMember{Member: pairs[i], Score: score})
+	}
+	return members, nil
+}
@@ -0,0 +1,230 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const (
+	ziplistHeaderSize = 10
+	ziplistEnd        = 0xFF
+	ziplistBigPrevLen = 0xFE
+
+	zipmapBigLen = 0xFE
+	zipmapEnd    = 0xFF
+)
+
+var errTruncatedEncoding = errors.New("truncated encoded value")
+
+func decodeZiplist(b []byte) ([]string, error) {
+	if len(b) < ziplistHeaderSize+1 {
+		return nil, errTruncatedEncoding
+	}
+
+	items := make([]string, 0, binary.LittleEndian.Uint16(b[8:10]))
+	pos := ziplistHeaderSize
+
+	for {
+		if pos >= len(b) {
+			return nil, errTruncatedEncoding
+		}
+		if b[pos] == ziplistEnd {
+			return items, nil
+		}
+
+		if b[pos] == ziplistBigPrevLen {
+			pos += 5
+		} else {
+			pos++
+		}
+		if pos >= len(b) {
+			return nil, errTruncatedEncoding
+		}
+
+		enc := b[pos]
+		var item string
+		var n int
+		var err error
+
+		switch enc >> 6 {
+		case 0b_00:
+			n = int(enc & 0x3F)
+			item, err = readZiplistString(b, pos+1, n)
+		case 0b_01:
+			if pos+1 >= len(b) {
+				return nil, errTruncatedEncoding
+			}
+			l := int(enc&0x3F)<<8 | int(b[pos+1])
+			item, err = readZiplistString(b, pos+2, l)
+			n = 1 + l
+		case 0b_10:
+			if pos+5 > len(b) {
+				return nil, errTruncatedEncoding
+			}
+			l := int(binary.BigEndian.Uint32(b[pos+1 : pos+5]))
+			item, err = readZiplistString(b, pos+5, l)
+			n = 4 + l
+		default:
+			var v int64
+			v, n, err = readZiplistInt(b, pos)
+			item = strconv.FormatInt(v, 10)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+		pos += 1 + n
+	}
+}
+
+// readZiplistString returns the l byte long string starting at pos.
+func readZiplistString(b []byte, pos, l int) (string, error) {
+	if l < 0 || pos+l > len(b) {
+		return "", errTruncatedEncoding
+	}
+	return string(b[pos : pos+l]), nil
+}
+
+// readZiplistInt decodes the integer whose encoding byte is at pos and
+// returns it with the number of payload bytes following the encoding byte.
+func readZiplistInt(b []byte, pos int) (int64, int, error) {
+	enc := b[pos]
+
+	var size int
+	switch enc {
+	case 0xC0:
+		size = 2
+	case 0xD0:
+		size = 4
+	case 0xE0:
+		size = 8
+	case 0xF0:
+		size = 3
+	case 0xFE:
+		size = 1
+	default:
+		// INFO: 1111xxxx stores 0-12 directly in the encoding byte as xxxx-1
+		if enc >= 0xF1 && enc <= 0xFD {
+			return int64(enc&0x0F) - 1, 0, nil
+		}
+		return 0, 0, fmt.Errorf("invalid ziplist encoding 0x%02X", enc)
+	}
+
+	if pos+1+size > len(b) {
+		return 0, 0, errTruncatedEncoding
+	}
+	data := b[pos+1 : pos+1+size]
+
+	switch size {
+	case 1:
+		return int64(int8(data[0])), size, nil
+	case 2:
+		return int64(int16(binary.LittleEndian.Uint16(data))), size, nil
+	case 3:
+		v := int32(uint32(data[0])<<8|uint32(data[1])<<16|uint32(data[2])<<24) >> 8
+		return int64(v), size, nil
+	case 4:
+		return int64(int32(binary.LittleEndian.Uint32(data))), size, nil
+	default:
+		return int64(binary.LittleEndian.Uint64(data)), size, nil
+	}
+}
+
+func decodeIntset(b []byte) ([]string, error) {
+	if len(b) < 8 {
+		return nil, errTruncatedEncoding
+	}
+
+	width := int(binary.LittleEndian.Uint32(b[0:4]))
+	length := int(binary.LittleEndian.Uint32(b[4:8]))
+
+	if width != 2 && width != 4 && width != 8 {
+		return nil, fmt.Errorf("invalid intset encoding: %d", width)
+	}
+	if 8+width*length > len(b) {
+		return nil, errTruncatedEncoding
+	}
+
+	items := make([]string, 0, length)
+	for i := range length {
+		data := b[8+i*width : 8+(i+1)*width]
+
+		var v int64
+		switch width {
+		case 2:
+			v = int64(int16(binary.LittleEndian.Uint16(data)))
+		case 4:
+			v = int64(int32(binary.LittleEndian.Uint32(data)))
+		default:
+			v = int64(binary.LittleEndian.Uint64(data))
+		}
+		items = append(items, strconv.FormatInt(v, 10))
+	}
+	return items, nil
+}
+
+// decodeZipmap decodes the pre-2.6 hash encoding into a flat list of
+// field, value pairs.
+func decodeZipmap(b []byte) ([]string, error) {
+	if len(b) < 1 {
+		return nil, errTruncatedEncoding
+	}
+
+	items := make([]string, 0)
+	pos := 1
+
+	readLen := func() (int, error) {
+		if pos >= len(b) {
+			return 0, errTruncatedEncoding
+		}
+		if b[pos] < zipmapBigLen {
+			pos++
+			return int(b[pos-1]), nil
+		}
+		if pos+5 > len(b) {
+			return 0, errTruncatedEncoding
+		}
+		l := int(binary.LittleEndian.Uint32(b[pos+1 : pos+5]))
+		pos += 5
+		return l, nil
+	}
+
+	for {
+		if pos >= len(b) {
+			return nil, errTruncatedEncoding
+		}
+		if b[pos] == zipmapEnd {
+			return items, nil
+		}
+
+		keyLen, err := readLen()
+		if err != nil {
+			return nil, err
+		}
+		if pos+keyLen > len(b) {
+			return nil, errTruncatedEncoding
+		}
+		key := string(b[pos : pos+keyLen])
+		pos += keyLen
+
+		valLen, err := readLen()
+		if err != nil {
+			return nil, err
+		}
+		if pos >= len(b) {
+			return nil, errTruncatedEncoding
+		}
+		free := int(b[pos])
+		pos++
+		if pos+valLen+free > len(b) {
+			return nil, errTruncatedEncoding
+		}
+		val := string(b[pos : pos+valLen])
+		pos += valLen + free
+
+		items = append(items, key, val)
+	}
+}
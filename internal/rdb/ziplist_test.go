@@ -0,0 +1,59 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// buildZiplist wraps raw entries, each starting with its prevlen byte, in a
+// ziplist header and end marker.
+func buildZiplist(count int, entries ...[]byte) []byte {
+	b := make([]byte, ziplistHeaderSize)
+	for _, e := range entries {
+		b = append(b, e...)
+	}
+	b = append(b, ziplistEnd)
+
+	binary.LittleEndian.PutUint32(b[0:4], uint32(len(b)))
+	binary.LittleEndian.PutUint16(b[8:10], uint16(count))
+	return b
+}
+
+func TestDecodeZiplist(t *testing.T) {
+	b := buildZiplist(4,
+		[]byte{0x00, 0x03, 'a', 'b', 'c'},
+		[]byte{0x05, 0x40, 0x02, 'h', 'i'},
+		[]byte{0x05, 0x80, 0x00, 0x00, 0x00, 0x01, 'x'},
+		[]byte{0x07, 0xFE, 0xF6},
+	)
+
+	got, err := decodeZiplist(b)
+	if err != nil {
+		t.Fatalf("decodeZiplist() error = %v", err)
+	}
+	if want := []string{"abc", "hi", "x", "-10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeZiplist() = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeZiplistTruncated(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"6 bit length", buildZiplist(1, []byte{0x00, 0x05, 'a', 'b'})[:14]},
+		// INFO: the length bytes end in 0xFF, so skipping a truncated entry
+		// by its header alone would land on what looks like the end marker
+		{"14 bit length", buildZiplist(1, []byte{0x00, 0x40, 0xFF})},
+		{"32 bit length", buildZiplist(1, []byte{0x00, 0x80, 0x00, 0x00, 0x00, 0xFF})},
+		{"int", buildZiplist(1, []byte{0x00, 0xD0, 0x01})[:13]},
+		{"missing end", buildZiplist(1, []byte{0x00, 0x01, 'a'})[:13]},
+	}
+
+	for _, tt := range tests {
+		if got, err := decodeZiplist(tt.b); err == nil {
+			t.Errorf("%s: decodeZiplist() = %q, want an error", tt.name, got)
+		}
+	}
+}
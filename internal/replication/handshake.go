@@ -0,0 +1,207 @@
+package replication
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/rdb"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/utils/resputil"
+)
+
+// handshake synchronizes with the master on conn, either continuing from its
+// backlog or loading the RDB of a full resync. The returned reader is
+// positioned at the start of the replication stream.
+func (l *Link) handshake(conn net.Conn, masterAddr string) (*bufio.Reader, error) {
+	appState := l.state
+	cfg := appState.ReadCfg()
+
+	reader := bufio.NewReader(conn)
+
+	pingCmd := resputil.BulkStringsToRESPArray([]string{"PING"})
+	_, err := conn.Write(pingCmd.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to send PING command: %w", err)
+	}
+
+	res, _, err := resp.DecodeRESPInputExact(reader, resp.RESPStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from master server: %w", err)
+	}
+	if val, ok := res.GetStringValue(); !ok || val != "PONG" {
+		return nil, errors.New("unexpected response from master server, expected 'PONG', got: " + val)
+	}
+
+	replconfRes := resputil.BulkStringsToRESPArray([]string{"REPLCONF", "listening-port", strconv.Itoa(cfg.Port)})
+	_, err = conn.Write(replconfRes.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to send REPLCONF listening-port command: %w", err)
+	}
+
+	res, _, err = resp.DecodeRESPInputExact(reader, resp.RESPStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from master server: %w", err)
+	}
+	if val, ok := res.GetStringValue(); !ok || val != "OK" {
+		return nil, errors.New("unexpected response from master server, expected 'OK', got: " + val)
+	}
+
+	// INFO: "capa eof" is not advertised, a diskless master then still sends
+	// the RDB with its length up front
+	replconfRes = resputil.BulkStringsToRESPArray([]string{"REPLCONF", "capa", "psync2"})
+	_, err = conn.Write(replconfRes.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to send REPLCONF capa command: %w", err)
+	}
+
+	res, _, err = resp.DecodeRESPInputExact(reader, resp.RESPStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from master server: %w", err)
+	}
+	if val, ok := res.GetStringValue(); !ok || val != "OK" {
+		return nil, errors.New("unexpected response from master server, expected 'OK', got: " + val)
+	}
+
+	// INFO: after losing the master, offer the cached replication ID and the
+	// next offset we need so the master can answer with +CONTINUE.
+	psyncArgs := []string{"PSYNC", "?", "-1"}
+	appState.ReadState(func(st state.ReplicaState) {
+		if st.MasterReplicationID != "" {
+			psyncArgs = []string{"PSYNC", st.MasterReplicationID, strconv.Itoa(st.ReplicationOffset + 1)}
+		}
+	})
+
+	psyncEncoded := resputil.BulkStringsToRESPArray(psyncArgs).Bytes()
+	_, err = conn.Write(psyncEncoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send PSYNC command: %w", err)
+	}
+	res, _, err = resp.DecodeRESPInputExact(reader, resp.RESPStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from master server: %w", err)
+	}
+
+	content, ok := res.GetStringValue()
+	if !ok {
+		return nil, errors.New("unexpected response from master server, expected string, got: " + res.GetType())
+	}
+
+	if content == "CONTINUE" || strings.HasPrefix(content, "CONTINUE ") {
+		if newID := strings.TrimSpace(strings.TrimPrefix(content, "CONTINUE")); newID != "" {
+			appState.WriteState(func(s *state.ReplicaState) {
+				s.MasterReplicationID = newID
+			})
+		}
+
+		fmt.Printf("Partial resynchronization with master %s succeeded\n", masterAddr)
+
+		return reader, nil
+	}
+
+	if !strings.HasPrefix(content, "FULLRESYNC ") {
+		return nil, errors.New("unexpected response from master server, expected 'FULLRESYNC', got: " + content)
+	}
+
+	parts := strings.SplitN(content, " ", 3)
+
+	if len(parts) != 3 {
+		return nil, errors.New("unexpected response format from master server, expected 'FULLRESYNC <replication_id> <offset>', got: " + content)
+	}
+
+	masterRepID := parts[1]
+	if masterRepID == "" {
+		return nil, errors.New("replication ID cannot be empty")
+	}
+	repOffset, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid replication offset: %w", err)
+	}
+
+	rdbBytes, err := readRDBPayload(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	rdbData, err := rdb.ParseRDB(bytes.NewReader(rdbBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RDB file from master: %w", err)
+	}
+
+	// INFO: the replication ID and offset are only recorded once the
+	// snapshot is loaded. Recording them before would let the next PSYNC
+	// continue on top of the old dataset should the transfer fail.
+	appState.SetDBs(rdbData.MapToStores(appState.DBCount()))
+	appState.SetMasterSync(masterRepID, repOffset)
+	l.db = 0
+
+	if err := appState.RestartAOF(); err != nil {
+		fmt.Printf("Failed to rewrite the AOF after the full resync: %s\n", err.Error())
+	}
+
+	fmt.Printf("Connected to master server at %s\n", masterAddr)
+
+	return reader, nil
+}
+
+// readRDBPayload reads the RDB sent after FULLRESYNC, either as "$<len>\r\n"
+// followed by len bytes or, for diskless transfers, as "$EOF:<mark>\r\n"
+// followed by the payload and the 40 byte mark.
+func readRDBPayload(reader *bufio.Reader) ([]byte, error) {
+	flag, err := reader.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RDB file length: %w", err)
+	}
+	if flag != '$' {
+		return nil, errors.New("unexpected response from master server when reading RDB file length, expected '$', got: " + string(flag))
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RDB file length: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+
+	if mark, ok := strings.CutPrefix(line, "EOF:"); ok {
+		if len(mark) != 40 {
+			return nil, errors.New("invalid RDB EOF mark: " + mark)
+		}
+
+		payload := make([]byte, 0)
+		for {
+			b, err := reader.ReadByte()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read RDB file: %w", err)
+			}
+			payload = append(payload, b)
+
+			if len(payload) >= len(mark) && b == mark[len(mark)-1] &&
+				string(payload[len(payload)-len(mark):]) == mark {
+				return payload[:len(payload)-len(mark)], nil
+			}
+		}
+	}
+
+	rdbLen, err := strconv.Atoi(line)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RDB file length: %w", err)
+	}
+
+	if rdbLen <= 0 {
+		return nil, errors.New("invalid RDB file length, must be greater than 0")
+	}
+
+	rdbBytes := make([]byte, rdbLen)
+	_, err = io.ReadFull(reader, rdbBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RDB file: %w", err)
+	}
+
+	return rdbBytes, nil
+}
@@ -0,0 +1,193 @@
+package replication
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/client"
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
+	"github.com/0x222fe/codecrafters-redis-go/internal/connection"
+	"github.com/0x222fe/codecrafters-redis-go/internal/resp"
+	"github.com/0x222fe/codecrafters-redis-go/internal/state"
+	"github.com/0x222fe/codecrafters-redis-go/internal/user"
+)
+
+const (
+	minRetryDelay = 100 * time.Millisecond
+	maxRetryDelay = 5 * time.Second
+)
+
+var (
+	ErrAlreadyConnected = errors.New("already connected to specified master")
+
+	errMasterLost = errors.New("lost connection to master")
+)
+
+// Executor runs a command received from the master, normally
+// handler.RunCommand. It is injected to avoid an import cycle with the
+// handler package, which controls the link through REPLICAOF.
+type Executor func(c *client.Client, s *state.AppState, cmd command.Command) error
+
+// Link supervises the connection to the master while the server is a
+// replica, reconnecting with exponential backoff whenever it is lost.
+type Link struct {
+	mu     sync.Mutex
+	state  *state.AppState
+	exec   Executor
+	master string
+	cancel context.CancelFunc
+	done   chan struct{}
+	// INFO: database selected by the master stream. It is kept across
+	// reconnections because a partial resync continues the stream where it
+	// stopped, without a new SELECT.
+	db int
+}
+
+func NewLink(s *state.AppState, exec Executor) *Link {
+	return &Link{
+		state: s,
+		exec:  exec,
+	}
+}
+
+// ReplicaOf makes the server a replica of host:port, dropping the link to
+// any previous master.
+func (l *Link) ReplicaOf(host string, port int) error {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cancel != nil && l.master == addr {
+		return ErrAlreadyConnected
+	}
+
+	l.stopLocked()
+	l.state.BecomeReplica(host, port)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.master, l.cancel, l.done = addr, cancel, make(chan struct{})
+	go l.run(ctx, addr, l.done)
+
+	return nil
+}
+
+// Promote stops replicating and turns the server into a master with a new
+// replication ID.
+func (l *Link) Promote() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stopLocked()
+	l.state.PromoteToMaster()
+}
+
+func (l *Link) stopLocked() {
+	if l.cancel == nil {
+		return
+	}
+
+	l.cancel()
+	<-l.done
+	l.master, l.cancel, l.done = "", nil, nil
+}
+
+func (l *Link) run(ctx context.Context, addr string, done chan struct{}) {
+	defer close(done)
+
+	delay := minRetryDelay
+	for {
+		err := l.connect(ctx, addr)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if errors.Is(err, errMasterLost) {
+			delay = minRetryDelay
+		}
+		fmt.Printf("Replication with master %s interrupted: %s, retrying in %s\n", addr, err.Error(), delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// connect runs a single session with the master: handshake, then the
+// replication stream until the connection breaks.
+func (l *Link) connect(ctx context.Context, addr string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return errors.New("failed to connect to master server: " + err.Error())
+	}
+	defer conn.Close()
+
+	// INFO: closing the connection unblocks any pending read when the
+	// link is stopped by REPLICAOF.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	reader, err := l.handshake(conn, addr)
+	if err != nil {
+		return err
+	}
+
+	l.serve(conn, reader)
+	return errMasterLost
+}
+
+func (l *Link) serve(rawConn net.Conn, reader *bufio.Reader) {
+	defer fmt.Println("Master connection closed")
+
+	appState := l.state
+	defaultUser, _ := appState.GetUser(user.DefaultUserName)
+	conn := connection.NewConnection(rawConn, defaultUser)
+
+	c := client.NewClient(context.Background(), conn)
+	c.Propagated = true
+	c.DB = l.db
+	defer func() {
+		l.db = c.DB
+	}()
+	for {
+		respVal, _, err := resp.DecodeRESPInputExact(reader, resp.RESPArr)
+		if err != nil {
+			var opErr *net.OpError
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) || errors.As(err, &opErr) {
+				return
+			}
+
+			fmt.Printf("Error reading from master: %s\n", err.Error())
+			continue
+		}
+
+		// INFO: the master counted these bytes in its offset whether or not
+		// they parse or run here, so they are always accounted for. Applied
+		// and accounted for as one write, so a full resync of our own
+		// replicas sees both or neither.
+		appState.BeginWrite()
+		cmd, err := command.ParseCommandFromRESP(respVal)
+		if err != nil {
+			fmt.Printf("Error parsing command from master: %s\n", err.Error())
+		} else {
+			fmt.Println("Received command from master:", cmd.Name)
+			if err := l.exec(c, appState, cmd); err != nil {
+				fmt.Printf("Error executing command from master: %s\n", err.Error())
+			}
+		}
+
+		appState.ApplyMasterStream(respVal.Bytes())
+		appState.EndWrite()
+	}
+}
@@ -118,6 +118,9 @@ func parseBulkStr(reader *countingReader) (RESPValue, error) {
 	if length == -1 {
 		return NewBulkString(nil), nil
 	}
+	if length < 0 {
+		return RESPValue{}, fmt.Errorf("invalid bulk string length %d", length)
+	}
 	data := make([]byte, length)
 	if _, err := io.ReadFull(reader, data); err != nil {
 		return RESPValue{}, err
@@ -2,40 +2,65 @@ package resp
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
 func (r RESPValue) Bytes() []byte {
+	return r.appendTo(nil)
+}
+
+// appendTo appends the encoding of r to buf. Bulk strings are copied verbatim,
+// so values are binary safe.
+func (r RESPValue) appendTo(buf []byte) []byte {
 	switch r.valType {
 	case RESPStr:
 		//INFO: strVal should never be nil for respStr and respErr
-		return fmt.Appendf(nil, "+%s\r\n", *r.strVal)
+		buf = append(buf, '+')
+		buf = append(buf, *r.strVal...)
+		return append(buf, '\r', '\n')
 	case RESPErr:
 		errType := strings.Split(*r.strVal, " ")[0]
 		switch errType {
-		case "WRONGPASS", "NOAUTH":
-			return fmt.Appendf(nil, "-%s\r\n", *r.strVal)
+		case "WRONGPASS", "NOAUTH", "WRONGTYPE", "OOM":
+			buf = append(buf, '-')
 		default:
-			return fmt.Appendf(nil, "-ERR %s\r\n", *r.strVal)
+			buf = append(buf, "-ERR "...)
 		}
+		buf = append(buf, *r.strVal...)
+		return append(buf, '\r', '\n')
 	case RESPBulkStr:
-		if r.strVal != nil {
-			return fmt.Appendf(nil, "$%d\r\n%s\r\n", len(*r.strVal), *r.strVal)
+		switch {
+		case r.strVal != nil:
+			buf = appendLength(buf, '$', len(*r.strVal))
+			buf = append(buf, *r.strVal...)
+		case r.bytesVal != nil:
+			buf = appendLength(buf, '$', len(r.bytesVal))
+			buf = append(buf, r.bytesVal...)
+		default:
+			return append(buf, "$-1\r\n"...)
 		}
-		return []byte("$-1\r\n")
+		return append(buf, '\r', '\n')
 	case RESPInt:
-		return fmt.Appendf(nil, ":%d\r\n", r.intVal)
+		buf = append(buf, ':')
+		buf = strconv.AppendInt(buf, r.intVal, 10)
+		return append(buf, '\r', '\n')
 	case RESPArr:
 		if r.arrVal == nil {
-			return []byte("*-1\r\n")
+			return append(buf, "*-1\r\n"...)
 		}
-		bytes := fmt.Appendf(nil, "*%d\r\n", len(r.arrVal))
+		buf = appendLength(buf, '*', len(r.arrVal))
 		for _, elem := range r.arrVal {
-			elemBytes := elem.Bytes()
-			bytes = append(bytes, elemBytes...)
+			buf = elem.appendTo(buf)
 		}
-		return bytes
+		return buf
 	default:
 		panic(fmt.Sprintf("unknown RESP value type: %d", r.valType))
 	}
 }
+
+func appendLength(buf []byte, prefix byte, n int) []byte {
+	buf = append(buf, prefix)
+	buf = strconv.AppendInt(buf, int64(n), 10)
+	return append(buf, '\r', '\n')
+}
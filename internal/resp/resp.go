@@ -5,8 +5,11 @@ type respValueType int
 type RESPValue struct {
 	valType respValueType
 	strVal  *string
-	intVal  int64
-	arrVal  []RESPValue
+	// INFO: set instead of strVal for bulk strings built from stored values,
+	// so they are written out without a conversion to string
+	bytesVal []byte
+	intVal   int64
+	arrVal   []RESPValue
 }
 
 const (
@@ -39,6 +42,15 @@ func NewBulkString(s *string) RESPValue {
 	return RESPValue{valType: RESPBulkStr, strVal: s}
 }
 
+// NewBulkBytes returns a bulk string holding b, which must not be modified
+// afterwards. A nil b is an empty string, not a nil bulk string.
+func NewBulkBytes(b []byte) RESPValue {
+	if b == nil {
+		b = []byte{}
+	}
+	return RESPValue{valType: RESPBulkStr, bytesVal: b}
+}
+
 func NewError(err error) RESPValue {
 	s := err.Error()
 	return RESPValue{valType: RESPErr, strVal: &s}
@@ -70,6 +82,10 @@ func (v RESPValue) GetStringValue() (string, bool) {
 
 func (v RESPValue) GetBulkStringValue() (*string, bool) {
 	if v.valType == RESPBulkStr {
+		if v.bytesVal != nil {
+			s := string(v.bytesVal)
+			return &s, true
+		}
 		return v.strVal, true
 	}
 	return nil, false
@@ -0,0 +1,116 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/aof"
+	"github.com/0x222fe/codecrafters-redis-go/internal/rdb"
+)
+
+var (
+	ErrAOFDisabled = errors.New("AOF is not enabled, set appendonly yes first")
+)
+
+// RewriteAOF starts a background rewrite that compacts the AOF into a new
+// RDB encoded base file built from the current dataset.
+func (s *AppState) RewriteAOF() error {
+	// INFO: writes in progress are already applied to the dataset but not
+	// yet appended, so wait for them before rotating the incr file and
+	// taking the snapshot the new base is built from
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.propMu.Lock()
+	defer s.propMu.Unlock()
+
+	s.mu.RLock()
+	a, cfg := s.aof, *s.cfg
+	s.mu.RUnlock()
+
+	if a == nil {
+		return ErrAOFDisabled
+	}
+
+	rw, err := a.StartRewrite()
+	if err != nil {
+		return err
+	}
+
+	// INFO: the new incr file starts without a SELECT
+	s.propDB = -1
+
+	dbs := s.Snapshot()
+	opts := rdb.Options{Compression: cfg.RDBCompression, AOFBase: true}
+
+	go func() {
+		err := rw.Commit(func(w io.Writer) error {
+			return rdb.EncodeRDB(w, dbs, opts)
+		})
+		if err != nil {
+			fmt.Printf("Background AOF rewrite failed: %s\n", err.Error())
+			return
+		}
+		fmt.Println("Background AOF rewrite finished successfully")
+	}()
+
+	return nil
+}
+
+// RestartAOF rebuilds the AOF from the dataset after it was replaced as a
+// whole, by a full resync with our master, so that the files no longer
+// replay the history the dataset was thrown away with. A rewrite already in
+// progress started from the old dataset and is waited for first. It is a
+// no-op when the AOF is disabled.
+func (s *AppState) RestartAOF() error {
+	for {
+		err := s.RewriteAOF()
+		switch {
+		case errors.Is(err, ErrAOFDisabled):
+			return nil
+		case errors.Is(err, aof.ErrRewriteInProgress):
+			time.Sleep(10 * time.Millisecond)
+		default:
+			return err
+		}
+	}
+}
+
+// maybeRewriteAOF triggers a rewrite once the AOF has grown past
+// auto-aof-rewrite-min-size and by auto-aof-rewrite-percentage since the
+// last rewrite. Callers hold propMu.
+func (s *AppState) maybeRewriteAOF(a *aof.AOF) {
+	cfg := s.ReadCfg()
+	if cfg.AutoAOFRewritePercentage <= 0 || a.Rewriting() {
+		return
+	}
+
+	current, base := a.Sizes()
+	if current < cfg.AutoAOFRewriteMinSize {
+		return
+	}
+	if base == 0 {
+		base = 1
+	}
+
+	growth := current*100/base - 100
+	if growth < int64(cfg.AutoAOFRewritePercentage) {
+		return
+	}
+
+	// INFO: the caller is in the middle of a write, which the rewrite has to
+	// wait for, so it is started in the background, one at a time
+	if !s.autoRewrite.CompareAndSwap(false, true) {
+		return
+	}
+
+	fmt.Printf("Starting automatic rewriting of AOF on %d%% growth\n", growth)
+	go func() {
+		defer s.autoRewrite.Store(false)
+		if err := s.RewriteAOF(); err != nil {
+			fmt.Printf("Automatic AOF rewrite failed to start: %s\n", err.Error())
+		}
+	}()
+}
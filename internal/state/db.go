@@ -0,0 +1,110 @@
+package state
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+	"github.com/google/uuid"
+)
+
+var ErrDBIndexOutOfRange = errors.New("DB index is out of range")
+
+// NewDatabases returns n empty databases.
+func NewDatabases(n int) []*store.Store {
+	dbs := make([]*store.Store, n)
+	for i := range dbs {
+		dbs[i] = store.NewStore()
+	}
+	return dbs
+}
+
+// GetDB returns the database at idx, which must be a valid index.
+func (s *AppState) GetDB(idx int) *store.Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dbs[idx]
+}
+
+func (s *AppState) DBCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.dbs)
+}
+
+func (s *AppState) ValidDB(idx int) bool {
+	return idx >= 0 && idx < s.DBCount()
+}
+
+// SetDBs replaces every database, e.g. after loading a snapshot. Missing
+// databases are created empty.
+func (s *AppState) SetDBs(dbs []*store.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(dbs) < len(s.dbs) {
+		dbs = append(dbs, store.NewStore())
+	}
+	s.dbs = dbs[:len(s.dbs)]
+	s.watchExpirationsLocked()
+}
+
+// SwapDBs exchanges the contents of two databases. Clients stay connected to
+// the same index and so see the other keyspace from now on.
+func (s *AppState) SwapDBs(a, b int) error {
+	if !s.ValidDB(a) || !s.ValidDB(b) {
+		return ErrDBIndexOutOfRange
+	}
+	if a != b {
+		s.GetDB(a).SwapData(s.GetDB(b))
+	}
+	return nil
+}
+
+func (s *AppState) FlushAll() {
+	s.mu.RLock()
+	dbs := s.dbs
+	s.mu.RUnlock()
+
+	for _, db := range dbs {
+		db.Flush()
+	}
+}
+
+// Snapshot copies every database, indexed by database number.
+func (s *AppState) Snapshot() [][]store.SnapshotEntry {
+	s.mu.RLock()
+	dbs := s.dbs
+	s.mu.RUnlock()
+
+	snapshot := make([][]store.SnapshotEntry, len(dbs))
+	for i, db := range dbs {
+		snapshot[i] = db.Snapshot()
+	}
+	return snapshot
+}
+
+// Unwatch drops the keys watched by a client in every database.
+func (s *AppState) Unwatch(connID uuid.UUID) {
+	s.mu.RLock()
+	dbs := s.dbs
+	s.mu.RUnlock()
+
+	for _, db := range dbs {
+		db.Unwatch(connID)
+	}
+}
+
+// WatchesValid reports whether none of the keys a client watches, in any
+// database, were modified.
+func (s *AppState) WatchesValid(connID uuid.UUID) bool {
+	s.mu.RLock()
+	dbs := s.dbs
+	s.mu.RUnlock()
+
+	for _, db := range dbs {
+		if !db.WatchesValid(connID) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package state
+
+import (
+	"errors"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+)
+
+var ErrOOM = errors.New("OOM command not allowed when used memory > 'maxmemory'.")
+
+// UsedMemory returns the approximate memory used by every database.
+func (s *AppState) UsedMemory() int64 {
+	s.mu.RLock()
+	dbs := s.dbs
+	s.mu.RUnlock()
+
+	var used int64
+	for _, db := range dbs {
+		used += db.MemoryUsage()
+	}
+	return used
+}
+
+// PerformEvictions evicts keys according to maxmemory-policy until the used
+// memory is back under maxmemory, propagating a DEL for each of them. It
+// returns ErrOOM when that is not possible. Replicas leave eviction to their
+// master.
+func (s *AppState) PerformEvictions() error {
+	cfg := s.ReadCfg()
+	if cfg.MaxMemory <= 0 {
+		return nil
+	}
+
+	var isReplica bool
+	s.ReadState(func(st ReplicaState) {
+		isReplica = st.IsReplica
+	})
+	if isReplica {
+		return nil
+	}
+
+	s.evictMu.Lock()
+	defer s.evictMu.Unlock()
+
+	policy := store.EvictionPolicy(cfg.MaxMemoryPolicy)
+	for s.UsedMemory() > cfg.MaxMemory {
+		if policy == store.NoEviction {
+			return ErrOOM
+		}
+
+		// INFO: pick the best of the samples taken from every database
+		bestDB, bestKey, bestScore := -1, "", 0.0
+		for i := range s.DBCount() {
+			key, score, ok := s.GetDB(i).EvictionCandidate(policy, cfg.MaxMemorySamples)
+			if ok && (bestDB < 0 || score > bestScore) {
+				bestDB, bestKey, bestScore = i, key, score
+			}
+		}
+		if bestDB < 0 {
+			return ErrOOM
+		}
+
+		s.BeginWrite()
+		if s.GetDB(bestDB).Delete(bestKey) {
+			s.Propagate(bestDB, command.Command{Name: command.DEL, Args: []string{bestKey}})
+			s.NotifyKeyspaceEvent(config.NotifyEvicted, "evicted", bestKey, bestDB)
+		}
+		s.EndWrite()
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package state
+
+import "time"
+
+// INFO: like Redis, a cycle may use at most a quarter of the time between
+// two cycles.
+const activeExpireTimePct = 25
+
+// StartActiveExpire runs the active expire cycle hz times per second. Only a
+// master expires keys actively; the DELs it emits keep replicas and the AOF
+// in step, so replicas never expire keys on their own schedule.
+func (s *AppState) StartActiveExpire() {
+	hz := s.ReadCfg().Hz
+	period := time.Second / time.Duration(hz)
+	budget := period * activeExpireTimePct / 100
+
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+
+		// INFO: the database to start the next cycle from, so a cycle
+		// running out of time does not starve the databases after it
+		next := 0
+		for range ticker.C {
+			var isReplica bool
+			s.ReadState(func(st ReplicaState) {
+				isReplica = st.IsReplica
+			})
+			if isReplica {
+				continue
+			}
+
+			start := time.Now()
+			count := s.DBCount()
+			for i := 0; i < count; i++ {
+				remaining := budget - time.Since(start)
+				if remaining <= 0 {
+					break
+				}
+
+				db := (next + i) % count
+				s.BeginWrite()
+				s.GetDB(db).ActiveExpireCycle(remaining)
+				s.PropagateExpired()
+				s.EndWrite()
+			}
+			next = (next + 1) % count
+		}
+	}()
+}
@@ -0,0 +1,71 @@
+package state
+
+import (
+	"strconv"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+)
+
+// NotifyKeyspaceEvent publishes event on key in database db to the
+// __keyspace@<db>__:<key> and __keyevent@<db>__:<event> channels, as far as
+// notify-keyspace-events enables class.
+func (s *AppState) NotifyKeyspaceEvent(class config.KeyspaceEvents, event, key string, db int) {
+	events := s.ReadCfg().NotifyKeyspaceEvents
+	if events&class == 0 {
+		return
+	}
+
+	prefix := "@" + strconv.Itoa(db) + "__:"
+	if events&config.NotifyKeyspace != 0 {
+		s.Publish("__keyspace"+prefix+key, []byte(event))
+	}
+	if events&config.NotifyKeyevent != 0 {
+		s.Publish("__keyevent"+prefix+event, []byte(key))
+	}
+}
+
+// watchExpirationsLocked has every database report keys it expires, whether
+// lazily on access or in the active expire cycle. The keys are queued for the
+// next Propagate, which cannot run from the handler as the database lock is
+// held.
+func (s *AppState) watchExpirationsLocked() {
+	for i, db := range s.dbs {
+		db.SetExpireHandler(func(key string) {
+			s.queueExpired(i, key)
+			s.NotifyKeyspaceEvent(config.NotifyExpired, "expired", key, i)
+		})
+	}
+}
+
+// expiredKey is a key removed from database db because it expired, waiting
+// for its DEL to be propagated.
+type expiredKey struct {
+	db  int
+	key string
+}
+
+func (s *AppState) queueExpired(db int, key string) {
+	s.expiredMu.Lock()
+	defer s.expiredMu.Unlock()
+	s.expired = append(s.expired, expiredKey{db: db, key: key})
+}
+
+func (s *AppState) hasExpired() bool {
+	s.expiredMu.Lock()
+	defer s.expiredMu.Unlock()
+	return len(s.expired) > 0
+}
+
+func (s *AppState) takeExpired() []expiredKey {
+	s.expiredMu.Lock()
+	defer s.expiredMu.Unlock()
+	expired := s.expired
+	s.expired = nil
+	return expired
+}
+
+// DropExpired forgets the keys found expired without propagating them, for
+// commands replayed from the AOF: the keys expire again on the next load.
+func (s *AppState) DropExpired() {
+	s.takeExpired()
+}
@@ -0,0 +1,119 @@
+package state
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
+)
+
+// outputBuffer queues encoded Pub/Sub frames for a subscriber until its
+// writer goroutine sends them, keeping track of how many bytes are pending
+// the way Redis accounts a client's output buffer.
+type outputBuffer struct {
+	mu            sync.Mutex
+	frames        [][]byte
+	pending       int64
+	softReachedAt time.Time
+	closed        bool
+	// INFO: signalled with capacity 1 so enqueue never blocks
+	wake chan struct{}
+}
+
+func newOutputBuffer() *outputBuffer {
+	return &outputBuffer{wake: make(chan struct{}, 1)}
+}
+
+// enqueue appends frame unless the buffer is closed. It returns false when
+// the frame was not queued, and overLimit when the frame pushed the buffer
+// past limit, in which case the buffer is closed and dropped holds the number
+// of frames discarded with it.
+func (b *outputBuffer) enqueue(frame []byte, limit config.OutputBufferLimit) (queued, overLimit bool, dropped int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return false, false, 0
+	}
+
+	b.frames = append(b.frames, frame)
+	b.pending += int64(len(frame))
+
+	if b.exceedsLocked(limit, time.Now()) {
+		dropped = len(b.frames)
+		b.closeLocked()
+		return false, true, dropped
+	}
+
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+	return true, false, 0
+}
+
+// exceedsLocked applies the same rules as Redis' checkClientOutputBufferLimits:
+// the hard limit disconnects at once, the soft one only after the buffer has
+// stayed above it for more than SoftSeconds.
+func (b *outputBuffer) exceedsLocked(limit config.OutputBufferLimit, now time.Time) bool {
+	if limit.Hard > 0 && b.pending >= limit.Hard {
+		return true
+	}
+
+	if limit.Soft > 0 && b.pending >= limit.Soft {
+		if b.softReachedAt.IsZero() {
+			b.softReachedAt = now
+			return false
+		}
+		return now.Sub(b.softReachedAt) > time.Duration(limit.SoftSeconds)*time.Second
+	}
+
+	b.softReachedAt = time.Time{}
+	return false
+}
+
+func (b *outputBuffer) closeLocked() {
+	b.closed = true
+	b.frames = nil
+	b.pending = 0
+}
+
+// take removes and returns the queued frames. The bytes stay counted as
+// pending until written is called.
+func (b *outputBuffer) take() [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	frames := b.frames
+	b.frames = nil
+	return frames
+}
+
+// written releases n bytes taken earlier.
+func (b *outputBuffer) written(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.closed {
+		b.pending -= n
+	}
+}
+
+// pubsubStats counts Pub/Sub output buffer overruns for INFO.
+type pubsubStats struct {
+	disconnections  atomic.Int64
+	droppedMessages atomic.Int64
+}
+
+// PubSubDisconnections returns the number of subscribers disconnected for
+// exceeding client-output-buffer-limit pubsub.
+func (s *AppState) PubSubDisconnections() int64 {
+	return s.pubsubStats.disconnections.Load()
+}
+
+// PubSubDroppedMessages returns the number of messages discarded along with
+// the subscribers they were queued for.
+func (s *AppState) PubSubDroppedMessages() int64 {
+	return s.pubsubStats.droppedMessages.Load()
+}
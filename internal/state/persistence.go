@@ -0,0 +1,39 @@
+package state
+
+import "time"
+
+type saveState struct {
+	inProgress bool
+	lastSave   time.Time
+	lastSaveOK bool
+}
+
+// StartSave marks a snapshot as running. It returns false when another SAVE
+// or BGSAVE is already in progress.
+func (s *AppState) StartSave() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.save.inProgress {
+		return false
+	}
+	s.save.inProgress = true
+	return true
+}
+
+func (s *AppState) FinishSave(ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.save.inProgress = false
+	s.save.lastSaveOK = ok
+	if ok {
+		s.save.lastSave = time.Now()
+	}
+}
+
+func (s *AppState) LastSave() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.save.lastSave
+}
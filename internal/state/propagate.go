@@ -0,0 +1,165 @@
+package state
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/aof"
+	"github.com/0x222fe/codecrafters-redis-go/internal/command"
+)
+
+func (s *AppState) SetAOF(a *aof.AOF) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aof = a
+}
+
+func (s *AppState) CloseAOF() error {
+	s.propMu.Lock()
+	defer s.propMu.Unlock()
+
+	s.mu.Lock()
+	a := s.aof
+	s.aof = nil
+	s.mu.Unlock()
+
+	if a == nil {
+		return nil
+	}
+	return a.Close()
+}
+
+// BeginWrite must be called before changing the dataset, and EndWrite once
+// the change has been propagated. Snapshots that the propagated stream
+// continues from, for AOF rewrites and full resyncs, wait for the writes in
+// progress, so every write is either in the snapshot or in the stream after
+// it, never both.
+func (s *AppState) BeginWrite() {
+	s.writeMu.RLock()
+}
+
+// EndWrite ends a write started with BeginWrite.
+func (s *AppState) EndWrite() {
+	s.writeMu.RUnlock()
+}
+
+// Propagate appends write commands executed in database db to the AOF and,
+// on a master, streams them to every connected replica. Commands passed in a
+// single call are written back to back, which keeps MULTI/EXEC blocks
+// contiguous. A SELECT among cmds switches the database of the commands
+// following it. On a master, DELs for the keys found expired since the last
+// call go out ahead of cmds, so they also precede a write that replaced an
+// expired key.
+func (s *AppState) Propagate(db int, cmds ...command.Command) {
+	if len(cmds) == 0 && !s.hasExpired() {
+		return
+	}
+
+	s.propMu.Lock()
+	defer s.propMu.Unlock()
+
+	s.mu.RLock()
+	a, isReplica := s.aof, s.replicaState.IsReplica
+	s.mu.RUnlock()
+
+	expired := s.takeExpired()
+	// INFO: a replica does not expire keys on its own behalf, the DELs of
+	// its master remove them from the dataset and the AOF
+	if isReplica {
+		expired = nil
+	}
+
+	encoded := make([]byte, 0)
+	for _, e := range expired {
+		del := command.Command{Name: command.DEL, Args: []string{e.key}}
+		encoded = s.appendEncodedLocked(encoded, e.db, del)
+	}
+	for _, cmd := range cmds {
+		if cmd.Name == command.SELECT {
+			if idx, err := strconv.Atoi(cmd.Args[0]); err == nil {
+				db = idx
+			}
+			continue
+		}
+		encoded = s.appendEncodedLocked(encoded, db, cmd)
+	}
+	if len(encoded) == 0 {
+		return
+	}
+
+	if a != nil {
+		if err := a.Write(encoded); err != nil {
+			fmt.Printf("failed to append to AOF: %v\n", err)
+		}
+		s.maybeRewriteAOF(a)
+	}
+
+	// INFO: a replica only records the master stream in its own AOF, the
+	// replication offset is advanced by the master link instead.
+	if isReplica {
+		return
+	}
+	s.feedReplicasLocked(encoded)
+}
+
+// PropagateExpired propagates DELs for the keys found expired since the last
+// propagation, see Propagate.
+func (s *AppState) PropagateExpired() {
+	s.Propagate(0)
+}
+
+// appendEncodedLocked appends cmd, executed in database db, to encoded,
+// preceded by a SELECT if the stream is on another database. propMu must be
+// held.
+func (s *AppState) appendEncodedLocked(encoded []byte, db int, cmd command.Command) []byte {
+	if db != s.propDB {
+		sel := command.Command{Name: command.SELECT, Args: []string{strconv.Itoa(db)}}
+		encoded = append(encoded, sel.EncodeRESP().Bytes()...)
+		s.propDB = db
+	}
+	return append(encoded, cmd.EncodeRESP().Bytes()...)
+}
+
+// PropagateToReplicas streams cmds to every connected replica without
+// appending them to the AOF, for commands that do not change the dataset but
+// still have to travel down the replication stream, like PUBLISH or
+// REPLCONF GETACK. cmds must not depend on the selected database, no SELECT
+// is sent ahead of them. It is a no-op on a replica.
+func (s *AppState) PropagateToReplicas(cmds ...command.Command) {
+	if len(cmds) == 0 {
+		return
+	}
+
+	s.propMu.Lock()
+	defer s.propMu.Unlock()
+
+	s.mu.RLock()
+	isReplica := s.replicaState.IsReplica
+	s.mu.RUnlock()
+	if isReplica {
+		return
+	}
+
+	encoded := make([]byte, 0)
+	for _, cmd := range cmds {
+		encoded = append(encoded, cmd.EncodeRESP().Bytes()...)
+	}
+	s.feedReplicasLocked(encoded)
+}
+
+// feedReplicasLocked advances the replication offset by encoded, records it
+// in the backlog and writes it to every replica. propMu must be held.
+func (s *AppState) feedReplicasLocked(encoded []byte) {
+	s.WriteState(func(st *ReplicaState) {
+		st.ReplicationOffset += len(encoded)
+	})
+	if s.backlog != nil {
+		s.backlog.Write(encoded)
+	}
+
+	for _, rep := range s.GetReplicas() {
+		if _, err := rep.Write(encoded); err != nil {
+			fmt.Printf("failed to propagate command to replica %s: %v\n", rep.Conn.RemoteAddr(), err)
+		}
+	}
+}
@@ -4,96 +4,237 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/0x222fe/codecrafters-redis-go/internal/config"
 	"github.com/0x222fe/codecrafters-redis-go/internal/connection"
+	"github.com/0x222fe/codecrafters-redis-go/internal/utils/globutil"
 	"github.com/0x222fe/codecrafters-redis-go/internal/utils/resputil"
 	"github.com/google/uuid"
 )
 
-const (
-	subChanBufSize = 16
-)
-
 type Subscriber struct {
 	Conn     *connection.Connection
 	Channels map[string]struct{}
-	MsgChan  chan PubSubMsg
-	Ctx      context.Context
-	Cancel   context.CancelFunc
+	Patterns map[string]struct{}
+	// INFO: shard channels are counted apart from Channels and Patterns in
+	// (un)subscribe replies, as in Redis
+	ShardChannels map[string]struct{}
+	Ctx           context.Context
+	Cancel        context.CancelFunc
+	out           *outputBuffer
 }
 
+// PubSubMsg is a message delivered to a subscriber. Pattern is set when it
+// was matched by a pattern subscription, Shard when it was sent with
+// SPUBLISH.
 type PubSubMsg struct {
+	Pattern string
 	Channel string
 	Payload []byte
+	Shard   bool
 }
 
-func (s *AppState) AddSubscriber(conn *connection.Connection, channel string) *Subscriber {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (msg PubSubMsg) frame() []byte {
+	if msg.Shard {
+		return resputil.BulkStringsToRESPArray([]string{
+			"smessage",
+			msg.Channel,
+			string(msg.Payload),
+		}).Bytes()
+	}
+	if msg.Pattern != "" {
+		return resputil.BulkStringsToRESPArray([]string{
+			"pmessage",
+			msg.Pattern,
+			msg.Channel,
+			string(msg.Payload),
+		}).Bytes()
+	}
+	return resputil.BulkStringsToRESPArray([]string{
+		"message",
+		msg.Channel,
+		string(msg.Payload),
+	}).Bytes()
+}
 
-	sub, ok := s.subscribers[conn.ID]
-	if !ok {
-		ctx, cancel := context.WithCancel(context.Background())
-		sub = &Subscriber{
-			Conn:     conn,
-			Ctx:      ctx,
-			Channels: make(map[string]struct{}),
-			Cancel:   cancel,
-			MsgChan:  make(chan PubSubMsg, subChanBufSize),
-		}
-		s.subscribers[conn.ID] = sub
-		go func() {
-			for {
-				select {
-				case msg := <-sub.MsgChan:
-					conn.WriteResp(resputil.BulkStringsToRESPArray([]string{
-						"message",
-						msg.Channel,
-						string(msg.Payload),
-					}))
-				case <-sub.Ctx.Done():
+// subscriptionCount is the number reported in (un)subscribe replies. Callers
+// hold s.mu.
+func (sub *Subscriber) subscriptionCount() int {
+	return len(sub.Channels) + len(sub.Patterns)
+}
+
+func (sub *Subscriber) writeLoop() {
+	for {
+		select {
+		case <-sub.out.wake:
+			for _, frame := range sub.out.take() {
+				if _, err := sub.Conn.Write(frame); err != nil {
 					return
 				}
+				sub.out.written(int64(len(frame)))
 			}
-		}()
+		case <-sub.Ctx.Done():
+			return
+		}
 	}
-	sub.Channels[channel] = struct{}{}
+}
 
-	chanMap, ok := s.channelSubs[channel]
-	if !ok {
-		chanMap = make(map[uuid.UUID]*Subscriber)
-		s.channelSubs[channel] = chanMap
+func (s *AppState) getOrAddSubscriberLocked(conn *connection.Connection) *Subscriber {
+	sub, ok := s.subscribers[conn.ID]
+	if ok {
+		return sub
 	}
 
-	chanMap[conn.ID] = sub
+	ctx, cancel := context.WithCancel(context.Background())
+	sub = &Subscriber{
+		Conn:          conn,
+		Ctx:           ctx,
+		Channels:      make(map[string]struct{}),
+		Patterns:      make(map[string]struct{}),
+		ShardChannels: make(map[string]struct{}),
+		Cancel:        cancel,
+		out:           newOutputBuffer(),
+	}
+	s.subscribers[conn.ID] = sub
+	go sub.writeLoop()
 
 	fmt.Printf("Subscriber connected: %s\n", conn.RemoteAddr().String())
 
 	return sub
 }
 
-func (s *AppState) UnsubChannel(id uuid.UUID, channel string) *Subscriber {
+// AddSubscriber subscribes conn to channel and returns its number of
+// subscriptions.
+func (s *AppState) AddSubscriber(conn *connection.Connection, channel string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub := s.getOrAddSubscriberLocked(conn)
+	sub.Channels[channel] = struct{}{}
+	addToRegistry(s.channelSubs, channel, sub)
+
+	return sub.subscriptionCount()
+}
+
+// AddPatternSubscriber subscribes conn to every channel matching the glob
+// pattern and returns its number of subscriptions.
+func (s *AppState) AddPatternSubscriber(conn *connection.Connection, pattern string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub := s.getOrAddSubscriberLocked(conn)
+	sub.Patterns[pattern] = struct{}{}
+	addToRegistry(s.patternSubs, pattern, sub)
+
+	return sub.subscriptionCount()
+}
+
+// AddShardSubscriber subscribes conn to the shard channel and returns its
+// number of shard subscriptions.
+func (s *AppState) AddShardSubscriber(conn *connection.Connection, channel string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub := s.getOrAddSubscriberLocked(conn)
+	sub.ShardChannels[channel] = struct{}{}
+	addToRegistry(s.shardSubs, channel, sub)
+
+	return len(sub.ShardChannels)
+}
+
+// UnsubChannel unsubscribes a client from channel and returns its number of
+// remaining subscriptions.
+func (s *AppState) UnsubChannel(id uuid.UUID, channel string) int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	sub, ok := s.subscribers[id]
 	if !ok {
-		return nil
+		return 0
 	}
 
-	chanMap, ok := s.channelSubs[channel]
+	delete(sub.Channels, channel)
+	removeFromRegistry(s.channelSubs, channel, id)
+
+	return sub.subscriptionCount()
+}
+
+// UnsubPattern unsubscribes a client from pattern and returns its number of
+// remaining subscriptions.
+func (s *AppState) UnsubPattern(id uuid.UUID, pattern string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subscribers[id]
 	if !ok {
-		return sub
+		return 0
 	}
 
-	_, ok = chanMap[id]
+	delete(sub.Patterns, pattern)
+	removeFromRegistry(s.patternSubs, pattern, id)
+
+	return sub.subscriptionCount()
+}
+
+// UnsubShardChannel unsubscribes a client from the shard channel and returns
+// its number of remaining shard subscriptions.
+func (s *AppState) UnsubShardChannel(id uuid.UUID, channel string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subscribers[id]
 	if !ok {
-		return sub
+		return 0
 	}
 
-	delete(chanMap, id)
-	delete(sub.Channels, channel)
+	delete(sub.ShardChannels, channel)
+	removeFromRegistry(s.shardSubs, channel, id)
 
-	return sub
+	return len(sub.ShardChannels)
+}
+
+// HasSubscriptions reports whether a client is subscribed to any channel,
+// pattern or shard channel.
+func (s *AppState) HasSubscriptions(id uuid.UUID) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subscribers[id]
+	return ok && sub.subscriptionCount()+len(sub.ShardChannels) > 0
+}
+
+// SubscribedPatterns returns the patterns a client is subscribed to.
+func (s *AppState) SubscribedPatterns(id uuid.UUID) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subscribers[id]
+	if !ok {
+		return nil
+	}
+
+	patterns := make([]string, 0, len(sub.Patterns))
+	for pattern := range sub.Patterns {
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// SubscribedShardChannels returns the shard channels a client is subscribed
+// to.
+func (s *AppState) SubscribedShardChannels(id uuid.UUID) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subscribers[id]
+	if !ok {
+		return nil
+	}
+
+	channels := make([]string, 0, len(sub.ShardChannels))
+	for channel := range sub.ShardChannels {
+		channels = append(channels, channel)
+	}
+	return channels
 }
 
 func (s *AppState) RemoveSubscriber(id uuid.UUID) {
@@ -104,7 +245,13 @@ func (s *AppState) RemoveSubscriber(id uuid.UUID) {
 		sub.Cancel()
 
 		for channel := range sub.Channels {
-			delete(s.channelSubs[channel], id)
+			removeFromRegistry(s.channelSubs, channel, id)
+		}
+		for pattern := range sub.Patterns {
+			removeFromRegistry(s.patternSubs, pattern, id)
+		}
+		for channel := range sub.ShardChannels {
+			removeFromRegistry(s.shardSubs, channel, id)
 		}
 
 		delete(s.subscribers, id)
@@ -112,23 +259,186 @@ func (s *AppState) RemoveSubscriber(id uuid.UUID) {
 	}
 }
 
+// Publish queues payload for the subscribers of channel and for those of
+// every pattern matching it, and returns the number of subscribers it was
+// queued for. Subscribers whose output buffer overflows are disconnected.
 func (s *AppState) Publish(channel string, payload []byte) int {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	limit := s.cfg.ClientOutputBufferLimits.PubSub
 
+	s.mu.RLock()
 	sent := 0
-	chanMap, ok := s.channelSubs[channel]
-	if !ok {
+	if subs := s.channelSubs[channel]; len(subs) > 0 {
+		frame := PubSubMsg{Channel: channel, Payload: payload}.frame()
+		for _, sub := range subs {
+			if s.deliverLocked(sub, frame, limit) {
+				sent++
+			}
+		}
+	}
+	patterns := registryNames(s.patternSubs)
+	s.mu.RUnlock()
+
+	// INFO: patterns are client supplied, so they are matched without
+	// holding s.mu, which every publish and subscribe needs
+	matched := patterns[:0]
+	for _, pattern := range patterns {
+		if globutil.Match(pattern, channel) {
+			matched = append(matched, pattern)
+		}
+	}
+	if len(matched) == 0 {
 		return sent
 	}
 
-	for _, sub := range chanMap {
-		select {
-		case sub.MsgChan <- PubSubMsg{Channel: channel, Payload: payload}:
-			sent++
-		default:
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, pattern := range matched {
+		frame := PubSubMsg{Pattern: pattern, Channel: channel, Payload: payload}.frame()
+		for _, sub := range s.patternSubs[pattern] {
+			if s.deliverLocked(sub, frame, limit) {
+				sent++
+			}
 		}
 	}
 
 	return sent
 }
+
+// SPublish queues payload for the subscribers of the shard channel and
+// returns the number of subscribers it was queued for. Patterns never match
+// shard channels.
+func (s *AppState) SPublish(channel string, payload []byte) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	limit := s.cfg.ClientOutputBufferLimits.PubSub
+
+	sent := 0
+	if subs := s.shardSubs[channel]; len(subs) > 0 {
+		frame := PubSubMsg{Channel: channel, Payload: payload, Shard: true}.frame()
+		for _, sub := range subs {
+			if s.deliverLocked(sub, frame, limit) {
+				sent++
+			}
+		}
+	}
+
+	return sent
+}
+
+// deliverLocked queues frame for sub and disconnects it when that overflows
+// its output buffer. The subscription itself is cleaned up once the
+// connection handler notices the closed connection.
+func (s *AppState) deliverLocked(sub *Subscriber, frame []byte, limit config.OutputBufferLimit) bool {
+	queued, overLimit, dropped := sub.out.enqueue(frame, limit)
+	if !overLimit {
+		return queued
+	}
+
+	s.pubsubStats.disconnections.Add(1)
+	s.pubsubStats.droppedMessages.Add(int64(dropped))
+	fmt.Printf("Disconnecting subscriber %s: pubsub output buffer limit exceeded, %d messages dropped\n",
+		sub.Conn.RemoteAddr().String(), dropped)
+
+	sub.Cancel()
+	sub.Conn.Close()
+	return false
+}
+
+// ActiveChannels returns the channels with at least one subscriber, limited to
+// those matching pattern when it is not empty.
+func (s *AppState) ActiveChannels(pattern string) []string {
+	s.mu.RLock()
+	names := registryNames(s.channelSubs)
+	s.mu.RUnlock()
+
+	return filterNames(names, pattern)
+}
+
+// ActiveShardChannels is ActiveChannels for shard channels.
+func (s *AppState) ActiveShardChannels(pattern string) []string {
+	s.mu.RLock()
+	names := registryNames(s.shardSubs)
+	s.mu.RUnlock()
+
+	return filterNames(names, pattern)
+}
+
+// NumSub returns the number of subscribers of each channel.
+func (s *AppState) NumSub(channels []string) []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return subscriberCounts(s.channelSubs, channels)
+}
+
+// ShardNumSub is NumSub for shard channels.
+func (s *AppState) ShardNumSub(channels []string) []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return subscriberCounts(s.shardSubs, channels)
+}
+
+// NumPat returns the number of distinct patterns subscribed to.
+func (s *AppState) NumPat() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.patternSubs)
+}
+
+func registryNames(registry map[string]map[uuid.UUID]*Subscriber) []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	return names
+}
+
+// filterNames keeps the names matching pattern, all of them when it is
+// empty. It is run without s.mu held, see Publish.
+func filterNames(names []string, pattern string) []string {
+	if pattern == "" {
+		return names
+	}
+
+	filtered := names[:0]
+	for _, name := range names {
+		if globutil.Match(pattern, name) {
+			filtered = append(filtered, name)
+		}
+	}
+	return filtered
+}
+
+func subscriberCounts(registry map[string]map[uuid.UUID]*Subscriber, names []string) []int {
+	counts := make([]int, len(names))
+	for i, name := range names {
+		counts[i] = len(registry[name])
+	}
+	return counts
+}
+
+func addToRegistry(registry map[string]map[uuid.UUID]*Subscriber, name string, sub *Subscriber) {
+	subs, ok := registry[name]
+	if !ok {
+		subs = make(map[uuid.UUID]*Subscriber)
+		registry[name] = subs
+	}
+	subs[sub.Conn.ID] = sub
+}
+
+// INFO: empty entries are dropped so the registry only holds active names
+func removeFromRegistry(registry map[string]map[uuid.UUID]*Subscriber, name string, id uuid.UUID) {
+	subs, ok := registry[name]
+	if !ok {
+		return
+	}
+
+	delete(subs, id)
+	if len(subs) == 0 {
+		delete(registry, name)
+	}
+}
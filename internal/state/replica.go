@@ -3,8 +3,11 @@ package state
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/connection"
+	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+	"github.com/0x222fe/codecrafters-redis-go/internal/types/ringbuffer"
 	"github.com/google/uuid"
 )
 
@@ -14,6 +17,12 @@ type Replica struct {
 	OffsetChan chan int
 	Ctx        context.Context
 	Cancel     context.CancelFunc
+
+	// INFO: while the initial RDB is being transferred the replication
+	// stream is queued in pending and flushed by FinishSync.
+	mu      sync.Mutex
+	syncing bool
+	pending []byte
 }
 
 type ReplicaState struct {
@@ -21,22 +30,147 @@ type ReplicaState struct {
 	MasterReplicationID string
 	ReplicationID       string
 	ReplicationOffset   int
+	// INFO: the history we followed before the last promotion, valid up
+	// to SecondReplOffset, so former siblings can still partially resync.
+	ReplicationID2   string
+	SecondReplOffset int
+}
+
+// StartFullSync registers conn as a replica that is about to receive a full
+// resynchronization and returns the dataset and the replication ID and
+// offset it corresponds to. Commands propagated from now on are held back
+// until FinishSync is called on the returned replica.
+func (s *AppState) StartFullSync(conn *connection.Connection) (*Replica, [][]store.SnapshotEntry, string, int) {
+	// INFO: wait for writes in progress, so each of them is either in the
+	// snapshot or queued for the replica, and the offset matches the dataset
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.propMu.Lock()
+	defer s.propMu.Unlock()
+
+	dbs := s.Snapshot()
+	// INFO: the replica loads the snapshot with database 0 selected
+	s.propDB = -1
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ensureBacklogLocked()
+
+	rep := s.addReplicaLocked(conn)
+	return rep, dbs, s.replicaState.ReplicationID, s.replicaState.ReplicationOffset
+}
+
+// StartPartialSync registers conn as a replica continuing from offset, the
+// first byte of the replication stream it is missing, and returns our
+// current replication ID. It fails when replID is not part of our history
+// or the backlog no longer holds everything since offset. The missing bytes
+// are sent by FinishSync.
+func (s *AppState) StartPartialSync(conn *connection.Connection, replID string, offset int) (*Replica, string, bool) {
+	s.propMu.Lock()
+	defer s.propMu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.replicaState
+	known := replID == st.ReplicationID ||
+		(replID == st.ReplicationID2 && offset <= st.SecondReplOffset)
+	if s.backlog == nil || !known {
+		return nil, "", false
+	}
+
+	missing, ok := s.backlog.Tail(st.ReplicationOffset + 1 - offset)
+	if !ok {
+		return nil, "", false
+	}
+
+	rep := s.addReplicaLocked(conn)
+	rep.pending = missing
+	return rep, st.ReplicationID, true
+}
+
+// SetMasterSync records the replication ID and offset of a full resync with
+// our master. The backlog is dropped since it no longer matches the offset.
+func (s *AppState) SetMasterSync(replID string, offset int) {
+	s.propMu.Lock()
+	defer s.propMu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.replicaState.MasterReplicationID = replID
+	s.replicaState.ReplicationOffset = offset
+	s.backlog = nil
 }
 
-func (s *AppState) AddReplica(conn *connection.Connection) {
+// ApplyMasterStream accounts for a command received from our master. It is
+// also kept in the backlog, so that after a promotion our former siblings
+// can continue from it.
+func (s *AppState) ApplyMasterStream(p []byte) {
+	s.propMu.Lock()
+	defer s.propMu.Unlock()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.replicaState.ReplicationOffset += len(p)
+	s.ensureBacklogLocked()
+	s.backlog.Write(p)
+}
+
+func (s *AppState) ensureBacklogLocked() {
+	if s.backlog == nil {
+		s.backlog = ringbuffer.New(int(s.cfg.ReplBacklogSize))
+	}
+}
+
+func (s *AppState) addReplicaLocked(conn *connection.Connection) *Replica {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	s.replicas[conn.ID] = &Replica{
+	rep := &Replica{
 		Conn:       conn,
 		Offset:     0,
 		OffsetChan: make(chan int, 1),
 		Ctx:        ctx,
 		Cancel:     cancel,
+		syncing:    true,
 	}
+	s.replicas[conn.ID] = rep
 	fmt.Printf("Replica connected: %s\n", conn.RemoteAddr().String())
+
+	return rep
+}
+
+// Write sends p to the replica, or queues it while the replica is still
+// receiving its initial RDB.
+func (r *Replica) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.syncing {
+		r.pending = append(r.pending, p...)
+		return len(p), nil
+	}
+	return r.Conn.Write(p)
+}
+
+// FinishSync sends everything queued during the RDB transfer and switches
+// the replica to the live replication stream.
+func (r *Replica) FinishSync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pending := r.pending
+	r.pending = nil
+	r.syncing = false
+
+	if len(pending) == 0 {
+		return nil
+	}
+	_, err := r.Conn.Write(pending)
+	return err
 }
 
 func (s *AppState) RemoveReplica(id uuid.UUID) {
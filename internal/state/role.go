@@ -0,0 +1,73 @@
+package state
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// ReplicationController switches the server between master and replica at
+// runtime. It is implemented by the replication link and registered on
+// startup so handlers can reach it without importing it.
+type ReplicationController interface {
+	ReplicaOf(host string, port int) error
+	Promote()
+}
+
+func (s *AppState) SetReplicationController(rc ReplicationController) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replication = rc
+}
+
+func (s *AppState) GetReplicationController() ReplicationController {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.replication
+}
+
+// BecomeReplica records host:port as the master. Replicas attached to us are
+// disconnected since their stream would stop until we resync.
+func (s *AppState) BecomeReplica(host string, port int) {
+	s.mu.Lock()
+	s.cfg.MasterHost, s.cfg.MasterPort = host, port
+	if !s.replicaState.IsReplica {
+		// INFO: offering our own history lets a promoted former replica
+		// of ours accept a partial resync.
+		s.replicaState.MasterReplicationID = s.replicaState.ReplicationID
+	}
+	s.replicaState.IsReplica = true
+	s.mu.Unlock()
+
+	for _, rep := range s.GetReplicas() {
+		rep.Conn.Close()
+	}
+}
+
+// PromoteToMaster turns a replica into a master with a fresh replication ID,
+// keeping its dataset and replication offset. The master's ID becomes our
+// secondary ID, valid up to the current offset.
+func (s *AppState) PromoteToMaster() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.replicaState
+	if !st.IsReplica {
+		return
+	}
+
+	s.cfg.MasterHost, s.cfg.MasterPort = "", 0
+	if st.MasterReplicationID != "" {
+		st.ReplicationID2 = st.MasterReplicationID
+		st.SecondReplOffset = st.ReplicationOffset + 1
+	}
+	st.IsReplica = false
+	st.MasterReplicationID = ""
+	st.ReplicationID = NewReplicationID()
+}
+
+// NewReplicationID returns a random 40 character hex replication ID.
+func NewReplicationID() string {
+	b := make([]byte, 20)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -2,9 +2,13 @@ package state
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/0x222fe/codecrafters-redis-go/internal/aof"
 	"github.com/0x222fe/codecrafters-redis-go/internal/config"
 	"github.com/0x222fe/codecrafters-redis-go/internal/store"
+	"github.com/0x222fe/codecrafters-redis-go/internal/types/ringbuffer"
 	"github.com/0x222fe/codecrafters-redis-go/internal/user"
 	"github.com/google/uuid"
 )
@@ -12,29 +16,66 @@ import (
 type AppState struct {
 	mu           sync.RWMutex
 	cfg          *config.Config
-	store        *store.Store
+	dbs          []*store.Store
 	replicaState *ReplicaState
 	replicas     map[uuid.UUID]*Replica
 	subscribers  map[uuid.UUID]*Subscriber
 	channelSubs  map[string]map[uuid.UUID]*Subscriber
+	patternSubs  map[string]map[uuid.UUID]*Subscriber
+	shardSubs    map[string]map[uuid.UUID]*Subscriber
+	pubsubStats  pubsubStats
 	users        map[string]*user.User
+	save         saveState
+	aof          *aof.AOF
+	// INFO: the replication backlog, created when the first replica
+	// attaches and guarded by propMu.
+	backlog     *ringbuffer.RingBuffer
+	replication ReplicationController
+	// INFO: serializes PerformEvictions so concurrent clients do not evict
+	// more than needed
+	evictMu sync.Mutex
+
+	// INFO: held for reading by every change to the dataset until it has
+	// been propagated, and for writing while a snapshot is taken that the
+	// propagated stream has to continue from. See BeginWrite.
+	writeMu sync.RWMutex
+	// INFO: serializes Propagate so the replication stream and the AOF see
+	// commands in the same order without holding mu during network writes.
+	propMu sync.Mutex
+	// INFO: set while an automatic AOF rewrite is being started
+	autoRewrite atomic.Bool
+	// INFO: database last selected in the propagated stream, -1 to force a
+	// SELECT before the next command. Guarded by propMu.
+	propDB int
+	// INFO: keys expired since the last Propagate, queued by the expire
+	// handlers of the databases which run with the database lock held
+	expiredMu sync.Mutex
+	expired   []expiredKey
 }
 
-func NewAppState(s *ReplicaState, cfg *config.Config, store *store.Store) *AppState {
+func NewAppState(s *ReplicaState, cfg *config.Config, dbs []*store.Store) *AppState {
 	defaultUser := user.New(user.DefaultUserName)
 	defaultUser.AddFlag(user.FlagNoPass)
 
 	appState := &AppState{
 		cfg:          cfg,
-		store:        store,
+		dbs:          dbs,
 		replicaState: s,
 		replicas:     make(map[uuid.UUID]*Replica),
 		subscribers:  make(map[uuid.UUID]*Subscriber),
 		channelSubs:  make(map[string]map[uuid.UUID]*Subscriber),
+		patternSubs:  make(map[string]map[uuid.UUID]*Subscriber),
+		shardSubs:    make(map[string]map[uuid.UUID]*Subscriber),
 		users: map[string]*user.User{
 			user.DefaultUserName: defaultUser,
 		},
+		save: saveState{
+			lastSave:   time.Now(),
+			lastSaveOK: true,
+		},
+		propDB: -1,
 	}
+	appState.watchExpirationsLocked()
 
 	return appState
 }
@@ -51,18 +92,6 @@ func (s *AppState) WriteState(f func(s *ReplicaState)) {
 	f(s.replicaState)
 }
 
-func (s *AppState) SetStore(store *store.Store) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.store = store
-}
-
-func (s *AppState) GetStore() *store.Store {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.store
-}
-
 func (s *AppState) ReadCfg() config.Config {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+type EvictionPolicy string
+
+const (
+	NoEviction     EvictionPolicy = "noeviction"
+	AllKeysLRU     EvictionPolicy = "allkeys-lru"
+	VolatileLRU    EvictionPolicy = "volatile-lru"
+	AllKeysLFU     EvictionPolicy = "allkeys-lfu"
+	VolatileLFU    EvictionPolicy = "volatile-lfu"
+	AllKeysRandom  EvictionPolicy = "allkeys-random"
+	VolatileRandom EvictionPolicy = "volatile-random"
+	VolatileTTL    EvictionPolicy = "volatile-ttl"
+)
+
+// INFO: Redis' defaults for new keys, lfu-log-factor and lfu-decay-time
+const (
+	lfuInitVal      = 5
+	lfuLogFactor    = 10
+	lfuDecayMinutes = 1
+)
+
+// touchLocked records an access to the item at key and returns it updated.
+func (store *Store) touchLocked(key string, item StoreItem) StoreItem {
+	now := time.Now().UnixMilli()
+	item.freq = lfuLogIncr(lfuDecayed(item, now))
+	item.accessedAt = now
+	store.data[key] = item
+	return item
+}
+
+// lfuDecayed returns the access counter of item, decremented once for every
+// lfuDecayMinutes it was not accessed.
+func lfuDecayed(item StoreItem, now int64) uint8 {
+	periods := (now - item.accessedAt) / int64(time.Minute/time.Millisecond) / lfuDecayMinutes
+	if periods >= int64(item.freq) {
+		return 0
+	}
+	return item.freq - uint8(periods)
+}
+
+// lfuLogIncr increments counter with a probability that falls as it grows,
+// so the 8 bits cover up to about a million accesses.
+func lfuLogIncr(counter uint8) uint8 {
+	if counter == math.MaxUint8 {
+		return counter
+	}
+
+	base := max(float64(counter)-lfuInitVal, 0)
+	if rand.Float64() < 1/(base*lfuLogFactor+1) {
+		counter++
+	}
+	return counter
+}
+
+// EvictionCandidate samples up to samples keys that policy may evict and
+// returns the best one to evict, along with a score that is higher for
+// better candidates so results from several stores can be compared.
+func (store *Store) EvictionCandidate(policy EvictionPolicy, samples int) (string, float64, bool) {
+	store.dataMu.RLock()
+	defer store.dataMu.RUnlock()
+
+	now := time.Now().UnixMilli()
+	best, bestScore, found := "", 0.0, false
+	consider := func(key string, item StoreItem) {
+		var score float64
+		switch policy {
+		case AllKeysLRU, VolatileLRU:
+			score = float64(now - item.accessedAt)
+		case AllKeysLFU, VolatileLFU:
+			score = float64(math.MaxUint8 - lfuDecayed(item, now))
+		case VolatileTTL:
+			score = -float64(*item.expireAt)
+		default:
+			score = rand.Float64()
+		}
+
+		if !found || score > bestScore {
+			best, bestScore, found = key, score, true
+		}
+	}
+
+	// INFO: map iteration order is randomized, which makes this a random
+	// sample
+	sampled := 0
+	switch policy {
+	case VolatileLRU, VolatileLFU, VolatileRandom, VolatileTTL:
+		for key := range store.expires {
+			if sampled == samples {
+				break
+			}
+			sampled++
+			consider(key, store.data[key])
+		}
+	case AllKeysLRU, AllKeysLFU, AllKeysRandom:
+		for key, item := range store.data {
+			if sampled == samples {
+				break
+			}
+			if item.val == nil {
+				continue
+			}
+			sampled++
+			consider(key, item)
+		}
+	}
+
+	return best, bestScore, found
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// setEvictable stores key with the access time and counter given, and an
+// expiry unless expireAt is 0.
+func setEvictable(st *Store, key string, expireAt, accessedAt int64, freq uint8) {
+	var exp *int64
+	if expireAt != 0 {
+		exp = &expireAt
+	}
+	st.Set(key, []byte("value"), String, exp)
+
+	item := st.data[key]
+	item.accessedAt, item.freq = accessedAt, freq
+	st.data[key] = item
+}
+
+func TestEvictionCandidate(t *testing.T) {
+	now := time.Now().UnixMilli()
+	hour, minute := time.Hour.Milliseconds(), time.Minute.Milliseconds()
+
+	// INFO: "old" and "rare" are the best candidates of all keys, "soon"
+	// and "volatile" the best among keys with an expiry
+	st := NewStore()
+	setEvictable(st, "old", 0, now-60*minute, 200)
+	setEvictable(st, "rare", 0, now, 0)
+	setEvictable(st, "recent", now+3*hour, now, 200)
+	setEvictable(st, "soon", now+hour, now, 200)
+	setEvictable(st, "volatile", now+2*hour, now-30*minute, 40)
+
+	tests := []struct {
+		policy EvictionPolicy
+		want   []string
+	}{
+		{AllKeysLRU, []string{"old"}},
+		{AllKeysLFU, []string{"rare"}},
+		{AllKeysRandom, []string{"old", "rare", "recent", "soon", "volatile"}},
+		{VolatileLRU, []string{"volatile"}},
+		{VolatileLFU, []string{"volatile"}},
+		{VolatileTTL, []string{"soon"}},
+		{VolatileRandom, []string{"recent", "soon", "volatile"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			for range 20 {
+				key, _, ok := st.EvictionCandidate(tt.policy, 10)
+				if !ok {
+					t.Fatal("EvictionCandidate() found no candidate")
+				}
+				if !slices.Contains(tt.want, key) {
+					t.Fatalf("EvictionCandidate() = %q, want one of %q", key, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEvictionCandidateWithoutExpires(t *testing.T) {
+	st := NewStore()
+	setEvictable(st, "key", 0, 0, 0)
+
+	for _, policy := range []EvictionPolicy{VolatileLRU, VolatileLFU, VolatileTTL, VolatileRandom, NoEviction} {
+		if key, _, ok := st.EvictionCandidate(policy, 10); ok {
+			t.Errorf("EvictionCandidate(%s) = %q, want none", policy, key)
+		}
+	}
+	if key, _, ok := st.EvictionCandidate(AllKeysLRU, 10); !ok || key != "key" {
+		t.Errorf("EvictionCandidate(%s) = %q, %v, want key", AllKeysLRU, key, ok)
+	}
+}
@@ -0,0 +1,49 @@
+package store
+
+import "time"
+
+const (
+	// INFO: same tuning as Redis' active expire cycle: sample 20 keys with
+	// an expiry and keep going while more than a quarter of them expired.
+	activeExpireSampleSize    = 20
+	activeExpireAcceptablePct = 25
+)
+
+// ActiveExpireCycle removes expired keys by repeatedly sampling keys that
+// have an expiry, until few enough of the sampled keys turn out expired or
+// budget is used up. It returns the keys it removed.
+func (store *Store) ActiveExpireCycle(budget time.Duration) []string {
+	start := time.Now()
+	expired := make([]string, 0)
+
+	for {
+		store.dataMu.Lock()
+		now := time.Now().UnixMilli()
+		sampled, hits := 0, 0
+		// INFO: map iteration order is randomized, which makes this a
+		// random sample
+		for key := range store.expires {
+			if sampled == activeExpireSampleSize {
+				break
+			}
+			sampled++
+
+			item := store.data[key]
+			if item.expireAt != nil && *item.expireAt < now {
+				store.expireLocked(key)
+				expired = append(expired, key)
+				hits++
+			}
+		}
+		store.dataMu.Unlock()
+
+		if sampled == 0 || hits*100 <= sampled*activeExpireAcceptablePct {
+			break
+		}
+		if time.Since(start) > budget {
+			break
+		}
+	}
+
+	return expired
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/types/scantable"
+)
+
+type RedisHash struct {
+	memTracker
+	mu     sync.RWMutex
+	fields map[string]string
+	table  *scantable.Table
+}
+
+func NewHash() *RedisHash {
+	return &RedisHash{
+		fields: make(map[string]string),
+		table:  scantable.New(),
+	}
+}
+
+func (h *RedisHash) Set(field, value string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	old, exists := h.fields[field]
+	h.fields[field] = value
+	if !exists {
+		h.table.Add(field)
+		h.grow(int64(hashFieldOverhead + len(field) + len(value)))
+	} else {
+		h.grow(int64(len(value) - len(old)))
+	}
+	return !exists
+}
+
+func (h *RedisHash) Get(field string) (string, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	v, ok := h.fields[field]
+	return v, ok
+}
+
+func (h *RedisHash) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.fields)
+}
+
+func (h *RedisHash) Fields() map[string]string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return maps.Clone(h.fields)
+}
+
+// Scan returns a batch of fields and their values, see Store.Scan.
+func (h *RedisHash) Scan(cursor uint64, count int) (map[string]string, uint64) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	fields, next := scanTable(h.table, cursor, count)
+	res := make(map[string]string, len(fields))
+	for _, f := range fields {
+		res[f] = h.fields[f]
+	}
+	return res, next
+}
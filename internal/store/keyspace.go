@@ -0,0 +1,141 @@
+package store
+
+import "sync"
+
+// INFO: taken by operations that lock two stores, so that they always lock
+// them one pair at a time and cannot deadlock each other.
+var crossStoreMu sync.Mutex
+
+// Size returns the number of keys, including expired ones that were not
+// reclaimed yet.
+func (store *Store) Size() int {
+	store.dataMu.RLock()
+	defer store.dataMu.RUnlock()
+
+	size := len(store.data)
+	for key := range store.watchedKeys {
+		if item, ok := store.data[key]; ok && item.val == nil {
+			size--
+		}
+	}
+	return size
+}
+
+// ExpiresSize returns the number of keys with an expiry.
+func (store *Store) ExpiresSize() int {
+	store.dataMu.RLock()
+	defer store.dataMu.RUnlock()
+	return len(store.expires)
+}
+
+// ObjectEncoding returns the name OBJECT ENCODING reports for the value at
+// key, without counting as an access. Containers have a single
+// representation each, reported under the name of the Redis encoding for
+// large values.
+func (store *Store) ObjectEncoding(key string) (string, bool) {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	item, ok := store.lookupLocked(key)
+	if !ok {
+		return "", false
+	}
+
+	switch item.valType {
+	case String:
+		return stringEncoding(item.val), true
+	case List:
+		return "quicklist", true
+	case Set, Hash:
+		return "hashtable", true
+	case ZSet:
+		return "skiplist", true
+	case Stream:
+		return "stream", true
+	default:
+		return "", false
+	}
+}
+
+// Flush deletes every key.
+func (store *Store) Flush() {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	for key, item := range store.data {
+		if item.val != nil {
+			store.deleteLocked(key)
+		}
+	}
+}
+
+// MoveTo moves key to dst, keeping its expiry. It returns false when key does
+// not exist or dst already holds it.
+func (store *Store) MoveTo(key string, dst *Store) bool {
+	crossStoreMu.Lock()
+	defer crossStoreMu.Unlock()
+
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+	dst.dataMu.Lock()
+	defer dst.dataMu.Unlock()
+
+	item, ok := store.liveItemLocked(key)
+	if !ok {
+		return false
+	}
+	if _, exists := dst.lookupLocked(key); exists {
+		return false
+	}
+
+	store.deleteLocked(key)
+
+	item.modCounter = dst.data[key].modCounter + 1
+	dst.putLocked(key, item)
+	dst.trackExpireLocked(key, item.expireAt)
+	return true
+}
+
+// SwapData exchanges the keys of two stores. Watches and blocked clients stay
+// with their store, and every watched key counts as modified.
+func (store *Store) SwapData(other *Store) {
+	crossStoreMu.Lock()
+	defer crossStoreMu.Unlock()
+
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+	other.dataMu.Lock()
+	defer other.dataMu.Unlock()
+
+	counters := make(map[string]uint32)
+	for _, st := range []*Store{store, other} {
+		for key := range st.watchedKeys {
+			counters[key] = max(counters[key], st.data[key].modCounter)
+		}
+	}
+
+	store.data, other.data = other.data, store.data
+	store.expires, other.expires = other.expires, store.expires
+	store.keyTable, other.keyTable = other.keyTable, store.keyTable
+	store.used, other.used = other.used, store.used
+
+	store.fixWatchedKeysLocked(counters)
+	other.fixWatchedKeysLocked(counters)
+}
+
+// fixWatchedKeysLocked restores the invariant that only watched keys have
+// tombstones after data was swapped in, and bumps the counter of every
+// watched key past counters.
+func (store *Store) fixWatchedKeysLocked(counters map[string]uint32) {
+	for key, item := range store.data {
+		if item.val == nil && store.watchedKeys[key] == 0 {
+			store.removeLocked(key)
+		}
+	}
+
+	for key := range store.watchedKeys {
+		item := store.data[key]
+		item.modCounter = max(item.modCounter, counters[key]) + 1
+		store.putLocked(key, item)
+	}
+}
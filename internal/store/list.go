@@ -1,10 +1,12 @@
 package store
 
 import (
+	"slices"
 	"sync"
 )
 
 type RedisList struct {
+	memTracker
 	mu   sync.RWMutex
 	list []string
 }
@@ -26,6 +28,7 @@ func (l *RedisList) LPush(items ...string) int {
 	}
 	copy(newList[n:], l.list)
 	l.list = newList
+	l.grow(listItemsSize(items))
 	return len(l.list)
 }
 
@@ -34,6 +37,7 @@ func (l *RedisList) RPush(items ...string) int {
 	defer l.mu.Unlock()
 
 	l.list = append(l.list, items...)
+	l.grow(listItemsSize(items))
 	return len(l.list)
 }
 
@@ -51,6 +55,7 @@ func (l *RedisList) LPop(count int) ([]string, bool) {
 
 	items := l.list[:count]
 	l.list = l.list[count:]
+	l.grow(-listItemsSize(items))
 	return items, true
 }
 
@@ -68,6 +73,7 @@ func (l *RedisList) RPop(count int) ([]string, bool) {
 
 	items := l.list[n-count:]
 	l.list = l.list[:n-count]
+	l.grow(-listItemsSize(items))
 	return items, true
 }
 
@@ -77,6 +83,12 @@ func (l *RedisList) Len() int {
 	return len(l.list)
 }
 
+func (l *RedisList) Items() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return slices.Clone(l.list)
+}
+
 func (l *RedisList) GetRange(start, end int) []string {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
@@ -102,3 +114,11 @@ func (l *RedisList) GetRange(start, end int) []string {
 	}
 	return l.list[start : end+1]
 }
+
+func listItemsSize(items []string) int64 {
+	var size int64
+	for _, item := range items {
+		size += int64(listItemOverhead + len(item))
+	}
+	return size
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// INFO: rough per item costs modelled on the Redis object layout (dict
+// entry, robj and sds headers). They only have to be in the right ballpark
+// for maxmemory to be useful.
+const (
+	keyOverhead         = 56
+	listItemOverhead    = 16
+	setMemberOverhead   = 24
+	hashFieldOverhead   = 32
+	zsetMemberOverhead  = 56
+	streamEntryOverhead = 64
+)
+
+// memTracker is embedded in container values to account for their size. While
+// a value is stored under a key, every change is also reported to the memory
+// counter of its store.
+type memTracker struct {
+	memMu   sync.Mutex
+	size    int64
+	counter *atomic.Int64
+}
+
+func (t *memTracker) grow(delta int64) {
+	t.memMu.Lock()
+	defer t.memMu.Unlock()
+
+	t.size += delta
+	if t.counter != nil {
+		t.counter.Add(delta)
+	}
+}
+
+func (t *memTracker) attachMemory(counter *atomic.Int64) {
+	t.memMu.Lock()
+	defer t.memMu.Unlock()
+
+	t.counter = counter
+	counter.Add(t.size)
+}
+
+func (t *memTracker) detachMemory() {
+	t.memMu.Lock()
+	defer t.memMu.Unlock()
+
+	if t.counter != nil {
+		t.counter.Add(-t.size)
+	}
+	t.counter = nil
+}
+
+type memoryTracked interface {
+	attachMemory(counter *atomic.Int64)
+	detachMemory()
+}
+
+// acquireLocked adds the cost of item stored at key to the memory counter.
+func (store *Store) acquireLocked(key string, item StoreItem) {
+	switch v := item.val.(type) {
+	case nil:
+		return
+	case []byte:
+		store.used.Add(int64(len(v)))
+	case memoryTracked:
+		v.attachMemory(store.used)
+	}
+	store.used.Add(int64(keyOverhead + len(key)))
+}
+
+// releaseLocked undoes acquireLocked.
+func (store *Store) releaseLocked(key string, item StoreItem) {
+	switch v := item.val.(type) {
+	case nil:
+		return
+	case []byte:
+		store.used.Add(-int64(len(v)))
+	case memoryTracked:
+		v.detachMemory()
+	}
+	store.used.Add(-int64(keyOverhead + len(key)))
+}
+
+// MemoryUsage returns the approximate number of bytes used by the keys and
+// values of the store.
+func (store *Store) MemoryUsage() int64 {
+	return store.used.Load()
+}
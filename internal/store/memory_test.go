@@ -0,0 +1,119 @@
+package store
+
+import "testing"
+
+func assertMemory(t *testing.T, st *Store, want int64) {
+	t.Helper()
+	if got := st.MemoryUsage(); got != want {
+		t.Fatalf("MemoryUsage() = %d, want %d", got, want)
+	}
+}
+
+func TestMemoryUsageStrings(t *testing.T) {
+	st := NewStore()
+
+	if _, err := st.SetString("key", "value", SetOptions{}); err != nil {
+		t.Fatalf("SetString() error = %v", err)
+	}
+	used := st.MemoryUsage()
+	if used <= 0 {
+		t.Fatalf("MemoryUsage() = %d after SET, want more than 0", used)
+	}
+
+	if _, err := st.SetString("key", "a much longer value", SetOptions{}); err != nil {
+		t.Fatalf("SetString() error = %v", err)
+	}
+	if got := st.MemoryUsage(); got <= used {
+		t.Errorf("MemoryUsage() = %d after overwriting with a longer value, want more than %d", got, used)
+	}
+	if _, err := st.SetString("key", "value", SetOptions{}); err != nil {
+		t.Fatalf("SetString() error = %v", err)
+	}
+	assertMemory(t, st, used)
+
+	if _, err := st.IncrBy("counter", 1); err != nil {
+		t.Fatalf("IncrBy() error = %v", err)
+	}
+	if _, err := st.MutateString("bits", 100, func(buf []byte) bool { return true }); err != nil {
+		t.Fatalf("MutateString() error = %v", err)
+	}
+
+	for _, key := range []string{"key", "counter", "bits"} {
+		if !st.Delete(key) {
+			t.Fatalf("Delete(%q) = false", key)
+		}
+	}
+	assertMemory(t, st, 0)
+}
+
+func TestMemoryUsageContainers(t *testing.T) {
+	st := NewStore()
+
+	list := NewList()
+	list.RPush("a", "b")
+	st.Set("list", list, List, nil)
+	used := st.MemoryUsage()
+
+	list.RPush("c")
+	if got := st.MemoryUsage(); got <= used {
+		t.Errorf("MemoryUsage() = %d after RPUSH, want more than %d", got, used)
+	}
+	list.LPop(1)
+	list.LPop(1)
+	list.LPush("a")
+
+	st.Delete("list")
+	assertMemory(t, st, 0)
+
+	// INFO: a value no longer stored must not count anymore
+	list.RPush("d")
+	assertMemory(t, st, 0)
+}
+
+func TestMemoryUsageAcrossStores(t *testing.T) {
+	a, b := NewStore(), NewStore()
+
+	list := NewList()
+	a.Set("list", list, List, nil)
+	a.Set("string", []byte("value"), String, nil)
+	used := a.MemoryUsage()
+
+	if !a.MoveTo("string", b) {
+		t.Fatal("MoveTo() = false")
+	}
+	aUsed, bUsed := a.MemoryUsage(), b.MemoryUsage()
+	if aUsed+bUsed != used || bUsed <= 0 {
+		t.Fatalf("MemoryUsage() after MOVE = %d and %d, want %d in total", aUsed, bUsed, used)
+	}
+
+	a.SwapData(b)
+	assertMemory(t, a, bUsed)
+	assertMemory(t, b, aUsed)
+
+	// INFO: the list moved to b along with its memory counter
+	list.RPush("a")
+	assertMemory(t, a, bUsed)
+	if got := b.MemoryUsage(); got <= aUsed {
+		t.Errorf("MemoryUsage() = %d after RPUSH to the swapped list, want more than %d", got, aUsed)
+	}
+
+	a.Flush()
+	b.Flush()
+	assertMemory(t, a, 0)
+	assertMemory(t, b, 0)
+
+	list.RPush("b")
+	assertMemory(t, a, 0)
+	assertMemory(t, b, 0)
+}
+
+func TestMemoryUsageExpired(t *testing.T) {
+	st := NewStore()
+
+	past := int64(1)
+	st.Set("key", []byte("value"), String, &past)
+	if st.Exists("key") {
+		t.Fatal("Exists() = true for an expired key")
+	}
+	assertMemory(t, st, 0)
+}
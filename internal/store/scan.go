@@ -0,0 +1,51 @@
+package store
+
+import (
+	"math"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/types/scantable"
+)
+
+// INFO: like Redis, a single SCAN call visits at most ten buckets per
+// requested element so sparse tables do not block the caller for long.
+const scanMaxBucketsPerElement = 10
+
+// scanTable walks t from cursor until count elements were collected or the
+// iteration completes, and returns them with the cursor to continue from.
+// count comes straight from the client, so it is neither used to size the
+// result nor multiplied without checking for overflow.
+func scanTable(t *scantable.Table, cursor uint64, count int) ([]string, uint64) {
+	var elements []string
+	buckets := math.MaxInt
+	if count <= math.MaxInt/scanMaxBucketsPerElement {
+		buckets = count * scanMaxBucketsPerElement
+	}
+
+	for {
+		cursor = t.Scan(cursor, func(s string) {
+			elements = append(elements, s)
+		})
+		buckets--
+		if cursor == 0 || buckets <= 0 || len(elements) >= count {
+			return elements, cursor
+		}
+	}
+}
+
+// Scan returns a batch of keys starting at cursor and the cursor to continue
+// from, 0 once every key was visited. Keys present for the whole iteration
+// are returned at least once, possibly more.
+func (store *Store) Scan(cursor uint64, count int) ([]string, uint64) {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	candidates, next := scanTable(store.keyTable, cursor, count)
+
+	keys := candidates[:0]
+	for _, key := range candidates {
+		if _, ok := store.lookupLocked(key); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, next
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// TestScanHugeCount checks that a client supplied COUNT neither sizes an
+// allocation nor overflows the bucket budget.
+func TestScanHugeCount(t *testing.T) {
+	st := NewStore()
+	want := make([]string, 0, 100)
+	for i := range 100 {
+		key := "key:" + strconv.Itoa(i)
+		st.Set(key, []byte("v"), String, nil)
+		want = append(want, key)
+	}
+
+	for _, count := range []int{math.MaxInt, math.MaxInt/scanMaxBucketsPerElement + 1, 1e9} {
+		keys, cursor := st.Scan(0, count)
+		if cursor != 0 {
+			t.Errorf("Scan(0, %d) cursor = %d, want 0", count, cursor)
+		}
+		slices.Sort(keys)
+		slices.Sort(want)
+		if !slices.Equal(keys, want) {
+			t.Errorf("Scan(0, %d) = %d keys, want %d", count, len(keys), len(want))
+		}
+	}
+}
+
+func TestSetScanHugeCount(t *testing.T) {
+	set := NewSet()
+	set.Add("a", "b", "c")
+
+	members, cursor := set.Scan(0, math.MaxInt)
+	slices.Sort(members)
+	if cursor != 0 || !slices.Equal(members, []string{"a", "b", "c"}) {
+		t.Errorf("Scan(0, MaxInt) = %q, %d, want [a b c], 0", members, cursor)
+	}
+}
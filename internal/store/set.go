@@ -0,0 +1,70 @@
+package store
+
+import (
+	"sync"
+
+	"github.com/0x222fe/codecrafters-redis-go/internal/types/scantable"
+)
+
+type RedisSet struct {
+	memTracker
+	mu      sync.RWMutex
+	members map[string]struct{}
+	table   *scantable.Table
+}
+
+func NewSet() *RedisSet {
+	return &RedisSet{
+		members: make(map[string]struct{}),
+		table:   scantable.New(),
+	}
+}
+
+func (s *RedisSet) Add(members ...string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added := 0
+	for _, m := range members {
+		if _, ok := s.members[m]; ok {
+			continue
+		}
+		s.members[m] = struct{}{}
+		s.table.Add(m)
+		s.grow(int64(setMemberOverhead + len(m)))
+		added++
+	}
+	return added
+}
+
+func (s *RedisSet) Contains(member string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.members[member]
+	return ok
+}
+
+func (s *RedisSet) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.members)
+}
+
+func (s *RedisSet) Members() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	members := make([]string, 0, len(s.members))
+	for m := range s.members {
+		members = append(members, m)
+	}
+	return members
+}
+
+// Scan returns a batch of members, see Store.Scan.
+func (s *RedisSet) Scan(cursor uint64, count int) ([]string, uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return scanTable(s.table, cursor, count)
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"bytes"
+	"time"
+)
+
+// SnapshotEntry is a point-in-time copy of a single key. Value holds an
+// immutable view of the data so it can be serialized without holding any
+// store locks:
+//
+//	String -> []byte
+//	List   -> []string
+//	Set    -> []string
+//	Hash   -> map[string]string
+//	ZSet   -> []SortedSetMember
+//	Stream -> []*StreamEntry
+type SnapshotEntry struct {
+	Key      string
+	Type     ValueType
+	Value    any
+	ExpireAt *int64
+}
+
+// Snapshot copies every live key in the store. Only the copy is done under
+// the store locks; the caller can encode the result at its own pace while
+// clients keep mutating the keyspace.
+func (store *Store) Snapshot() []SnapshotEntry {
+	now := time.Now().UnixMilli()
+
+	store.dataMu.RLock()
+	entries := make([]SnapshotEntry, 0, len(store.data))
+	for key, item := range store.data {
+		if item.val == nil {
+			continue
+		}
+		if item.expireAt != nil && *item.expireAt < now {
+			continue
+		}
+
+		entry := SnapshotEntry{
+			Key:      key,
+			Type:     item.valType,
+			ExpireAt: item.expireAt,
+		}
+
+		switch v := item.val.(type) {
+		case []byte:
+			// INFO: read locked, so a buffer MutateString may still change
+			// is copied instead of given up, see shareStringLocked
+			entry.Value = v
+			if item.owned {
+				entry.Value = bytes.Clone(v)
+			}
+		case int64:
+			entry.Value, _ = stringBytes(item)
+		case *RedisList:
+			entry.Value = v.Items()
+		case *RedisSet:
+			entry.Value = v.Members()
+		case *RedisHash:
+			entry.Value = v.Fields()
+		case *RedisSortedSet:
+			entry.Value = v.Members()
+		case *RedisStream:
+			entry.Value = v.Range(nil, nil)
+		default:
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	store.dataMu.RUnlock()
+
+	return entries
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"sync"
 
+	"github.com/0x222fe/codecrafters-redis-go/internal/types/scantable"
 	"github.com/0x222fe/codecrafters-redis-go/internal/types/sortedset"
 )
 
@@ -11,126 +12,221 @@ type SortedSetMember struct {
 	Member string
 }
 
-type sortedSetEntry struct {
-	mu  sync.RWMutex
-	set *sortedset.SortedSet
+type RedisSortedSet struct {
+	memTracker
+	mu    sync.RWMutex
+	set   *sortedset.SortedSet
+	table *scantable.Table
 }
 
-func (store *Store) AddToSortedSet(key string, members []SortedSetMember) int {
-	store.sortedSetMu.Lock()
-	entry, ok := store.sortedSetEntries[key]
-	if !ok {
-		entry = &sortedSetEntry{
-			set: sortedset.New(),
-		}
-		store.sortedSetEntries[key] = entry
+func NewSortedSet() *RedisSortedSet {
+	return &RedisSortedSet{
+		set:   sortedset.New(),
+		table: scantable.New(),
 	}
+}
 
-	entry.mu.Lock()
-	store.sortedSetMu.Unlock()
-	defer entry.mu.Unlock()
+// Add inserts or updates members and returns how many were new and whether
+// anything changed.
+func (z *RedisSortedSet) Add(members ...SortedSetMember) (int, bool) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
 
-	count := 0
+	added, changed := 0, false
 	for _, m := range members {
-		count += entry.set.Set(m.Member, m.Score)
+		if score, ok := z.set.Get(m.Member); ok && score == m.Score {
+			continue
+		}
+		if z.set.Set(m.Member, m.Score) == 1 {
+			z.table.Add(m.Member)
+			z.grow(int64(zsetMemberOverhead + len(m.Member)))
+			added++
+		}
+		changed = true
 	}
-	return count
+	return added, changed
 }
 
-func (store *Store) QuerySortedSetRank(key string, member string) (int, bool) {
-	store.sortedSetMu.RLock()
-	entry, ok := store.sortedSetEntries[key]
-	if !ok {
-		store.sortedSetMu.RUnlock()
-		return -1, false
+func (z *RedisSortedSet) Remove(member string) bool {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	if !z.set.Remove(member) {
+		return false
 	}
+	z.table.Remove(member)
+	z.grow(-int64(zsetMemberOverhead + len(member)))
+	return true
+}
 
-	entry.mu.RLock()
-	store.sortedSetMu.RUnlock()
-	defer entry.mu.RUnlock()
+func (z *RedisSortedSet) Rank(member string) (int, bool) {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	return z.set.Rank(member)
+}
 
-	rank, ok := entry.set.Rank(member)
-	return rank, ok
+func (z *RedisSortedSet) Score(member string) (float64, bool) {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	return z.set.Get(member)
 }
 
-func (store *Store) ListSortedSetMembersByRank(key string, start, end int) []string {
-	store.sortedSetMu.RLock()
-	entry, ok := store.sortedSetEntries[key]
-	if !ok {
-		store.sortedSetMu.RUnlock()
-		return []string{}
+func (z *RedisSortedSet) Len() int {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	return z.set.Len()
+}
+
+func (z *RedisSortedSet) RangeByRank(start, end int) []string {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	return z.set.RangeByRank(start, end)
+}
+
+func (z *RedisSortedSet) RangeByScore(min, max float64) []SortedSetMember {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	return z.withScores(z.set.RangeByScore(min, max))
+}
+
+// Members returns every member with its score, in rank order.
+func (z *RedisSortedSet) Members() []SortedSetMember {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+	return z.withScores(z.set.RangeByRank(0, -1))
+}
+
+// Scan returns a batch of members with their scores, see Store.Scan.
+func (z *RedisSortedSet) Scan(cursor uint64, count int) ([]SortedSetMember, uint64) {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+
+	names, next := scanTable(z.table, cursor, count)
+	return z.withScores(names), next
+}
+
+func (z *RedisSortedSet) withScores(names []string) []SortedSetMember {
+	members := make([]SortedSetMember, 0, len(names))
+	for _, name := range names {
+		score, _ := z.set.Get(name)
+		members = append(members, SortedSetMember{Score: score, Member: name})
 	}
+	return members
+}
 
-	entry.mu.RLock()
-	store.sortedSetMu.RUnlock()
-	defer entry.mu.RUnlock()
+// getSortedSet returns the sorted set at key, nil if the key does not exist
+// or ERRWrongType if it holds another type.
+func (store *Store) getSortedSet(key string) (*RedisSortedSet, error) {
+	v, t, ok := store.Get(key)
+	if !ok {
+		return nil, nil
+	}
 
-	return entry.set.RangeByRank(start, end)
+	z, isZSet := v.(*RedisSortedSet)
+	if t != ZSet || !isZSet {
+		return nil, ERRWrongType
+	}
+	return z, nil
 }
 
-func (store *Store) CountSortedSetMembers(key string) int {
-	store.sortedSetMu.RLock()
-	entry, ok := store.sortedSetEntries[key]
+// AddToSortedSet adds or updates members of the sorted set at key, creating
+// it if needed. It returns how many members were new and whether the set
+// changed at all.
+func (store *Store) AddToSortedSet(key string, members []SortedSetMember) (int, bool, error) {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	item, ok := store.liveItemLocked(key)
 	if !ok {
-		store.sortedSetMu.RUnlock()
-		return 0
+		item = StoreItem{val: NewSortedSet(), valType: ZSet, modCounter: item.modCounter}
 	}
 
-	entry.mu.RLock()
-	store.sortedSetMu.RUnlock()
-	defer entry.mu.RUnlock()
+	z, isZSet := item.val.(*RedisSortedSet)
+	if item.valType != ZSet || !isZSet {
+		return 0, false, ERRWrongType
+	}
 
-	return entry.set.Len()
+	added, changed := z.Add(members...)
+	if changed {
+		item.modCounter++
+		store.putLocked(key, item)
+	}
+	return added, changed, nil
 }
 
-func (store *Store) QuerySortedSetScore(key, member string) (float64, bool) {
-	store.sortedSetMu.RLock()
-	entry, ok := store.sortedSetEntries[key]
-	if !ok {
-		store.sortedSetMu.RUnlock()
-		return 0, false
+func (store *Store) QuerySortedSetRank(key string, member string) (int, bool, error) {
+	z, err := store.getSortedSet(key)
+	if z == nil {
+		return -1, false, err
 	}
 
-	entry.mu.RLock()
-	store.sortedSetMu.RUnlock()
-	defer entry.mu.RUnlock()
+	rank, ok := z.Rank(member)
+	return rank, ok, nil
+}
+
+func (store *Store) ListSortedSetMembersByRank(key string, start, end int) ([]string, error) {
+	z, err := store.getSortedSet(key)
+	if z == nil {
+		return []string{}, err
+	}
 
-	return entry.set.Get(member)
+	return z.RangeByRank(start, end), nil
 }
 
-func (store *Store) RemoveSortedSetMember(key, member string) bool {
-	store.sortedSetMu.RLock()
-	entry, ok := store.sortedSetEntries[key]
-	if !ok {
-		store.sortedSetMu.RUnlock()
-		return false
+func (store *Store) CountSortedSetMembers(key string) (int, error) {
+	z, err := store.getSortedSet(key)
+	if z == nil {
+		return 0, err
 	}
 
-	entry.mu.Lock()
-	store.sortedSetMu.RUnlock()
-	defer entry.mu.Unlock()
+	return z.Len(), nil
+}
+
+func (store *Store) QuerySortedSetScore(key, member string) (float64, bool, error) {
+	z, err := store.getSortedSet(key)
+	if z == nil {
+		return 0, false, err
+	}
 
-	return entry.set.Remove(member)
+	score, ok := z.Score(member)
+	return score, ok, nil
 }
 
-func (store *Store) QuerySortedSetMemberByScore(key string, min, max float64) []SortedSetMember {
-	store.sortedSetMu.RLock()
-	entry, ok := store.sortedSetEntries[key]
+// RemoveSortedSetMember removes member from the sorted set at key, deleting
+// the key once the set is empty.
+func (store *Store) RemoveSortedSetMember(key, member string) (bool, error) {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	item, ok := store.liveItemLocked(key)
 	if !ok {
-		store.sortedSetMu.RUnlock()
-		return []SortedSetMember{}
+		return false, nil
 	}
 
-	entry.mu.Lock()
-	store.sortedSetMu.RUnlock()
-	defer entry.mu.Unlock()
+	z, isZSet := item.val.(*RedisSortedSet)
+	if item.valType != ZSet || !isZSet {
+		return false, ERRWrongType
+	}
 
-	names := entry.set.RangeByScore(min, max)
-	members := make([]SortedSetMember, 0, len(names))
+	if !z.Remove(member) {
+		return false, nil
+	}
 
-	for _, name := range names {
-		score, _ := entry.set.Get(name)
-		members = append(members, SortedSetMember{Score: score, Member: name})
+	if z.Len() == 0 {
+		store.deleteLocked(key)
+		return true, nil
 	}
-	return members
-}
\ No newline at end of file
+
+	item.modCounter++
+	store.putLocked(key, item)
+	return true, nil
+}
+
+func (store *Store) QuerySortedSetMemberByScore(key string, min, max float64) ([]SortedSetMember, error) {
+	z, err := store.getSortedSet(key)
+	if z == nil {
+		return []SortedSetMember{}, err
+	}
+
+	return z.RangeByScore(min, max), nil
+}
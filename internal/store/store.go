@@ -3,9 +3,11 @@ package store
 import (
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/0x222fe/codecrafters-redis-go/internal/types/orderedmap"
+	"github.com/0x222fe/codecrafters-redis-go/internal/types/scantable"
 	"github.com/google/uuid"
 )
 
@@ -31,9 +33,16 @@ type WatchRegistry map[uuid.UUID]map[string]uint32
 type Store struct {
 	dataMu sync.RWMutex
 	data   map[string]StoreItem
-
-	sortedSetMu      sync.RWMutex
-	sortedSetEntries map[string]*sortedSetEntry
+	// INFO: mirrors the keys of data so SCAN can iterate them with a cursor
+	keyTable *scantable.Table
+	// INFO: approximate memory used by data. A pointer because values report
+	// their growth to it directly, so it moves along with data on SWAPDB.
+	used *atomic.Int64
+	// INFO: keys with an expiry, sampled by the active expire cycle
+	expires map[string]struct{}
+	// INFO: how many clients watch each key. Deleted keys that are watched
+	// stay in data as tombstones so their modCounter survives.
+	watchedKeys map[string]int
 
 	streamMu         sync.RWMutex
 	streamRegistries map[string]StreamInsertHandlerRegistry
@@ -43,12 +52,19 @@ type Store struct {
 
 	watchMu       sync.RWMutex
 	watchRegistry WatchRegistry
+
+	// INFO: called with dataMu held for every key that is removed because it
+	// expired
+	expireHandler func(key string)
 }
 
 func NewStore() *Store {
 	return &Store{
 		data:             make(map[string]StoreItem),
-		sortedSetEntries: make(map[string]*sortedSetEntry),
+		keyTable:         scantable.New(),
+		used:             new(atomic.Int64),
+		expires:          make(map[string]struct{}),
+		watchedKeys:      make(map[string]int),
 		streamRegistries: make(map[string]StreamInsertHandlerRegistry),
 		listRegistries:   make(map[string]*ListPushChanRgistry),
 		watchRegistry:    make(WatchRegistry),
@@ -60,6 +76,13 @@ type StoreItem struct {
 	valType    ValueType
 	expireAt   *int64
 	modCounter uint32
+	// INFO: last access as unix time in milliseconds and the logarithmic
+	// access counter, used by the LRU and LFU eviction policies
+	accessedAt int64
+	freq       uint8
+	// INFO: set while a []byte val was allocated by MutateString and not
+	// handed out since, so that it can still be changed in place
+	owned bool
 }
 
 var (
@@ -70,14 +93,8 @@ func (store *Store) Get(key string) (any, ValueType, bool) {
 	store.dataMu.Lock()
 	defer store.dataMu.Unlock()
 
-	item, ok := store.data[key]
-
-	if !ok || item.val == nil {
-		return nil, None, false
-	}
-
-	if item.expireAt != nil && *item.expireAt < time.Now().UnixMilli() {
-		store.deleteLocked(key)
+	item, ok := store.liveItemLocked(key)
+	if !ok {
 		return nil, None, false
 	}
 
@@ -107,18 +124,69 @@ func (store *Store) Set(key string, val any, valType ValueType, expireAt *int64)
 		counter = item.modCounter
 	}
 
-	store.data[key] = StoreItem{
+	store.putLocked(key, StoreItem{
 		val:        val,
 		valType:    valType,
 		expireAt:   expireAt,
 		modCounter: counter + 1,
-	}
+	})
+	store.trackExpireLocked(key, expireAt)
 }
 
-func (store *Store) Delete(key string) {
+// Delete removes key and reports whether it existed.
+func (store *Store) Delete(key string) bool {
 	store.dataMu.Lock()
 	defer store.dataMu.Unlock()
+
+	if _, ok := store.liveItemLocked(key); !ok {
+		return false
+	}
 	store.deleteLocked(key)
+	return true
+}
+
+func (store *Store) Exists(key string) bool {
+	_, _, ok := store.Get(key)
+	return ok
+}
+
+// GetExpire returns the expiry of key as a unix time in milliseconds, nil if
+// the key is persistent. ok is false when the key does not exist.
+func (store *Store) GetExpire(key string) (expireAt *int64, ok bool) {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	item, ok := store.liveItemLocked(key)
+	if !ok {
+		return nil, false
+	}
+	return item.expireAt, true
+}
+
+// SetExpire sets the expiry of key, or makes it persistent when expireAt is
+// nil. It returns false when the key does not exist.
+func (store *Store) SetExpire(key string, expireAt *int64) bool {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	item, ok := store.liveItemLocked(key)
+	if !ok {
+		return false
+	}
+
+	item.expireAt = expireAt
+	item.modCounter++
+	store.putLocked(key, item)
+	store.trackExpireLocked(key, expireAt)
+	return true
+}
+
+func (store *Store) trackExpireLocked(key string, expireAt *int64) {
+	if expireAt != nil {
+		store.expires[key] = struct{}{}
+	} else {
+		delete(store.expires, key)
+	}
 }
 
 func (store *Store) Type(key string) string {
@@ -130,13 +198,15 @@ func (store *Store) Type(key string) string {
 	return string(vType)
 }
 
+// Keys returns every live key, expiring the expired ones it comes across
+// like any other lookup, so KEYS agrees with SCAN.
 func (store *Store) Keys() []string {
-	store.dataMu.RLock()
-	defer store.dataMu.RUnlock()
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
 
 	keys := make([]string, 0, len(store.data))
-	for key, item := range store.data {
-		if item.val != nil {
+	for key := range store.data {
+		if _, ok := store.lookupLocked(key); ok {
 			keys = append(keys, key)
 		}
 	}
@@ -221,9 +291,9 @@ func (store *Store) NotifyListPush(listKey string, value string) {
 
 func (store *Store) Watch(keys []string, connID uuid.UUID) {
 	store.watchMu.Lock()
-	store.dataMu.RLock()
+	store.dataMu.Lock()
 	defer store.watchMu.Unlock()
-	defer store.dataMu.RUnlock()
+	defer store.dataMu.Unlock()
 
 	clientMap, ok := store.watchRegistry[connID]
 	if !ok {
@@ -232,6 +302,10 @@ func (store *Store) Watch(keys []string, connID uuid.UUID) {
 	}
 
 	for _, key := range keys {
+		if _, ok := clientMap[key]; !ok {
+			store.watchedKeys[key]++
+		}
+
 		var currCounter uint32
 		item, ok := store.data[key]
 		if ok {
@@ -243,8 +317,21 @@ func (store *Store) Watch(keys []string, connID uuid.UUID) {
 
 func (store *Store) Unwatch(connID uuid.UUID) {
 	store.watchMu.Lock()
+	store.dataMu.Lock()
 	defer store.watchMu.Unlock()
+	defer store.dataMu.Unlock()
+
+	for key := range store.watchRegistry[connID] {
+		store.watchedKeys[key]--
+		if store.watchedKeys[key] > 0 {
+			continue
+		}
 
+		delete(store.watchedKeys, key)
+		if item, ok := store.data[key]; ok && item.val == nil {
+			store.removeLocked(key)
+		}
+	}
 	delete(store.watchRegistry, connID)
 }
 
@@ -268,11 +355,90 @@ func (store *Store) WatchesValid(connID uuid.UUID) bool {
 	return true
 }
 
+// liveItemLocked returns the item at key unless it is deleted or expired,
+// recording the access. A missing item still carries the key's modification
+// counter.
+func (store *Store) liveItemLocked(key string) (StoreItem, bool) {
+	item, ok := store.lookupLocked(key)
+	if !ok {
+		return item, false
+	}
+	return store.touchLocked(key, item), true
+}
+
+// lookupLocked is liveItemLocked without recording an access.
+func (store *Store) lookupLocked(key string) (StoreItem, bool) {
+	item, ok := store.data[key]
+	if !ok || item.val == nil {
+		return item, false
+	}
+
+	if item.expireAt != nil && *item.expireAt < time.Now().UnixMilli() {
+		store.expireLocked(key)
+		return store.data[key], false
+	}
+
+	return item, true
+}
+
+// SetExpireHandler registers h to be told about every key that expires.
+// It must not call back into the store.
+func (store *Store) SetExpireHandler(h func(key string)) {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+	store.expireHandler = h
+}
+
+func (store *Store) expireLocked(key string) {
+	store.deleteLocked(key)
+	if store.expireHandler != nil {
+		store.expireHandler(key)
+	}
+}
+
 func (store *Store) deleteLocked(key string) {
+	delete(store.expires, key)
+
 	item, ok := store.data[key]
+	if !ok {
+		return
+	}
+
+	if store.watchedKeys[key] == 0 {
+		store.removeLocked(key)
+		return
+	}
+
+	item.val = nil
+	item.expireAt = nil
+	item.modCounter += 1
+	store.putLocked(key, item)
+}
+
+func (store *Store) putLocked(key string, item StoreItem) {
+	old, ok := store.data[key]
 	if ok {
-		item.val = nil
-		item.modCounter += 1
-		store.data[key] = item
+		store.releaseLocked(key, old)
+	} else {
+		store.keyTable.Add(key)
+	}
+
+	if item.val != nil && item.accessedAt == 0 {
+		item.accessedAt = time.Now().UnixMilli()
+		item.freq = lfuInitVal
+		if old.val != nil {
+			item.freq = old.freq
+		}
+	}
+
+	store.acquireLocked(key, item)
+	store.data[key] = item
+}
+
+func (store *Store) removeLocked(key string) {
+	if item, ok := store.data[key]; ok {
+		store.releaseLocked(key, item)
 	}
+	delete(store.data, key)
+	store.keyTable.Remove(key)
 }
@@ -0,0 +1,28 @@
+package store
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestKeysSkipsExpired(t *testing.T) {
+	st := NewStore()
+	past := time.Now().Add(-time.Second).UnixMilli()
+	future := time.Now().Add(time.Hour).UnixMilli()
+	st.Set("expired", []byte("v"), String, &past)
+	st.Set("expiring", []byte("v"), String, &future)
+	st.Set("persistent", []byte("v"), String, nil)
+
+	keys := st.Keys()
+	slices.Sort(keys)
+	if want := []string{"expiring", "persistent"}; !slices.Equal(keys, want) {
+		t.Errorf("Keys() = %q, want %q", keys, want)
+	}
+
+	scanned, _ := st.Scan(0, 10)
+	slices.Sort(scanned)
+	if !slices.Equal(scanned, keys) {
+		t.Errorf("Scan() = %q, Keys() = %q, want the same keys", scanned, keys)
+	}
+}
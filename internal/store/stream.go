@@ -14,6 +14,7 @@ import (
 )
 
 type RedisStream struct {
+	memTracker
 	mu   sync.RWMutex
 	tree *iradix.Tree[*StreamEntry]
 }
@@ -129,6 +130,12 @@ func (stream *RedisStream) AddEntry(idStr string, fields map[string]string) (*St
 	tree, _, _ := stream.tree.Insert(key, entry)
 	stream.tree = tree
 
+	size := int64(streamEntryOverhead)
+	for field, value := range fields {
+		size += int64(len(field) + len(value))
+	}
+	stream.grow(size)
+
 	return entry, nil
 }
 
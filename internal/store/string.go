@@ -0,0 +1,418 @@
+package store
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// INFO: string values are held as an int64 when they are canonical integers,
+// like Redis' OBJ_ENCODING_INT, and as a []byte otherwise. Stored byte slices
+// are handed out without copying, so they are never modified in place, except
+// by MutateString on a buffer it allocated itself and that was not handed out
+// since, see shareStringLocked.
+
+// stringPrealloc is Redis' SDS_MAX_PREALLOC: a buffer MutateString grows
+// gets twice the room it needs, or this much more once it is larger.
+const stringPrealloc = 1 << 20
+
+// MaxStringLength is the largest string value, Redis' default
+// proto-max-bulk-len.
+const MaxStringLength = 512 << 20
+
+// INFO: the longest string Redis allocates together with its object header
+const embstrMaxLen = 44
+
+var (
+	ERRStringTooLong = errors.New("string exceeds maximum allowed size (proto-max-bulk-len)")
+	ERRNotInteger    = errors.New("value is not an integer or out of range")
+	ERROverflow      = errors.New("increment or decrement would overflow")
+)
+
+// newStringValue returns the representation stored for s.
+func newStringValue(s string) any {
+	if len(s) <= 20 {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(n, 10) == s {
+			return n
+		}
+	}
+	return []byte(s)
+}
+
+// stringBytes returns the contents of a string item, and false when item
+// holds another type.
+func stringBytes(item StoreItem) ([]byte, bool) {
+	if item.valType != String {
+		return nil, false
+	}
+
+	switch v := item.val.(type) {
+	case []byte:
+		return v, true
+	case int64:
+		return strconv.AppendInt(nil, v, 10), true
+	default:
+		return nil, false
+	}
+}
+
+// shareStringLocked is stringBytes for a value about to be handed out of the
+// store. A buffer owned by MutateString is given up, so that it is copied
+// before being changed again.
+func (store *Store) shareStringLocked(key string, item *StoreItem) ([]byte, bool) {
+	b, ok := stringBytes(*item)
+	if ok && item.owned {
+		item.owned = false
+		store.data[key] = *item
+	}
+	return b, ok
+}
+
+// stringEncoding returns the OBJECT ENCODING of a string value.
+func stringEncoding(val any) string {
+	switch v := val.(type) {
+	case int64:
+		return "int"
+	case []byte:
+		if len(v) <= embstrMaxLen {
+			return "embstr"
+		}
+	}
+	return "raw"
+}
+
+// SetCondition restricts when SetString writes the key.
+type SetCondition int
+
+const (
+	SetAlways SetCondition = iota
+	// SetIfNotExists is SET NX
+	SetIfNotExists
+	// SetIfExists is SET XX
+	SetIfExists
+)
+
+// SetOptions are the options of SET. ExpireAt is an absolute unix time in
+// milliseconds, KeepTTL keeps the expiry of the current value instead.
+type SetOptions struct {
+	Condition SetCondition
+	ExpireAt  *int64
+	KeepTTL   bool
+	// INFO: GET makes SetString fail with ERRWrongType when the current value
+	// is not a string, even if the condition would not let it write
+	Get bool
+}
+
+// SetResult describes what SetString did. Old and HadOld are only filled in
+// when SetOptions.Get is set.
+type SetResult struct {
+	Old     []byte
+	HadOld  bool
+	Written bool
+}
+
+// SetString checks the condition and writes the string value under a single
+// lock, so concurrent SET NX or XX calls cannot both succeed.
+func (store *Store) SetString(key, val string, opts SetOptions) (SetResult, error) {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	var res SetResult
+	old, exists := store.liveItemLocked(key)
+
+	if opts.Get && exists {
+		b, ok := store.shareStringLocked(key, &old)
+		if !ok {
+			return res, ERRWrongType
+		}
+		res.Old, res.HadOld = b, true
+	}
+
+	switch opts.Condition {
+	case SetIfNotExists:
+		if exists {
+			return res, nil
+		}
+	case SetIfExists:
+		if !exists {
+			return res, nil
+		}
+	}
+
+	expireAt := opts.ExpireAt
+	if opts.KeepTTL && exists {
+		expireAt = old.expireAt
+	}
+
+	store.putStringLocked(key, newStringValue(val), expireAt)
+	res.Written = true
+	return res, nil
+}
+
+// putStringLocked stores a string value, bumping the modCounter of whatever
+// was stored at key before.
+func (store *Store) putStringLocked(key string, val any, expireAt *int64) {
+	// INFO: read the raw item, a watched tombstone still carries the
+	// modCounter WATCH compares against
+	counter := store.data[key].modCounter
+	store.putLocked(key, StoreItem{
+		val:        val,
+		valType:    String,
+		expireAt:   expireAt,
+		modCounter: counter + 1,
+	})
+	store.trackExpireLocked(key, expireAt)
+}
+
+// GetString returns the string stored at key. It fails with ERRWrongType
+// when the key holds another type. The result must not be modified.
+func (store *Store) GetString(key string) ([]byte, bool, error) {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	item, ok := store.liveItemLocked(key)
+	if !ok {
+		return nil, false, nil
+	}
+
+	b, ok := store.shareStringLocked(key, &item)
+	if !ok {
+		return nil, false, ERRWrongType
+	}
+	return b, true, nil
+}
+
+// UpdateString replaces the string at key with the result of fn, which is
+// called with the current value under the store lock. fn must not modify old
+// and has to return a new slice. The key keeps its expiry. Nothing is written
+// when fn returns an error or write is false.
+func (store *Store) UpdateString(key string, fn func(old []byte, exists bool) (val []byte, write bool, err error)) ([]byte, error) {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	item, exists := store.liveItemLocked(key)
+	var old []byte
+	if exists {
+		b, ok := store.shareStringLocked(key, &item)
+		if !ok {
+			return nil, ERRWrongType
+		}
+		old = b
+	}
+
+	val, write, err := fn(old, exists)
+	if err != nil || !write {
+		return val, err
+	}
+	if len(val) > MaxStringLength {
+		return nil, ERRStringTooLong
+	}
+
+	var expireAt *int64
+	if exists {
+		expireAt = item.expireAt
+	}
+
+	store.putStringLocked(key, val, expireAt)
+	return val, nil
+}
+
+// MutateString lets fn change the string at key in place, after padding it
+// with zero bytes to at least size bytes, and reports whether the key was
+// written. fn reports whether it changed buf. A missing key is created and
+// an existing one keeps its expiry.
+//
+// The first call copies the value into a buffer with room to grow, which
+// later calls change in place until the value is handed out again, so that
+// setting a bit in a large bitmap does not copy the whole of it.
+func (store *Store) MutateString(key string, size int, fn func(buf []byte) bool) (bool, error) {
+	if size > MaxStringLength {
+		return false, ERRStringTooLong
+	}
+
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	item, exists := store.liveItemLocked(key)
+	var cur []byte
+	if exists {
+		b, ok := stringBytes(item)
+		if !ok {
+			return false, ERRWrongType
+		}
+		cur = b
+	}
+
+	n := max(len(cur), size)
+	var buf []byte
+	if item.owned && cap(cur) >= n {
+		buf = cur[:n]
+		clear(buf[len(cur):])
+	} else {
+		buf = make([]byte, n, stringCapacity(n))
+		copy(buf, cur)
+	}
+
+	if !fn(buf) && exists && n == len(cur) {
+		return false, nil
+	}
+
+	var expireAt *int64
+	if exists {
+		expireAt = item.expireAt
+	}
+	store.putStringLocked(key, buf, expireAt)
+
+	item = store.data[key]
+	item.owned = true
+	store.data[key] = item
+	return true, nil
+}
+
+// stringCapacity returns the room allocated for a string of n bytes grown by
+// MutateString.
+func stringCapacity(n int) int {
+	if n < stringPrealloc {
+		return min(2*n, MaxStringLength)
+	}
+	return min(n+stringPrealloc, MaxStringLength)
+}
+
+// IncrBy adds delta to the integer stored at key, a missing key counting as
+// 0, and returns the result. The key keeps its expiry.
+func (store *Store) IncrBy(key string, delta int64) (int64, error) {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	item, exists := store.liveItemLocked(key)
+	var n int64
+	if exists {
+		if item.valType != String {
+			return 0, ERRWrongType
+		}
+
+		switch v := item.val.(type) {
+		case int64:
+			n = v
+		case []byte:
+			parsed, err := strconv.ParseInt(string(v), 10, 64)
+			if err != nil {
+				return 0, ERRNotInteger
+			}
+			n = parsed
+		}
+	}
+
+	if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+		return 0, ERROverflow
+	}
+	n += delta
+
+	var expireAt *int64
+	if exists {
+		expireAt = item.expireAt
+	}
+
+	store.putStringLocked(key, n, expireAt)
+	return n, nil
+}
+
+// MSet sets every key to its value, pairs alternating keys and values, and
+// clears their expiry. With nx nothing is written if any of the keys exists,
+// and MSet reports whether it wrote.
+func (store *Store) MSet(pairs []string, nx bool) bool {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	if nx {
+		for i := 0; i < len(pairs); i += 2 {
+			if _, ok := store.liveItemLocked(pairs[i]); ok {
+				return false
+			}
+		}
+	}
+
+	for i := 0; i < len(pairs); i += 2 {
+		store.putStringLocked(pairs[i], newStringValue(pairs[i+1]), nil)
+	}
+	return true
+}
+
+// GetDel returns the string at key and deletes it.
+func (store *Store) GetDel(key string) ([]byte, bool, error) {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	item, ok := store.liveItemLocked(key)
+	if !ok {
+		return nil, false, nil
+	}
+
+	b, ok := store.shareStringLocked(key, &item)
+	if !ok {
+		return nil, false, ERRWrongType
+	}
+
+	store.deleteLocked(key)
+	return b, true, nil
+}
+
+// GetEx returns the string at key and, when update is set, replaces its
+// expiry with expireAt, nil making it persistent.
+func (store *Store) GetEx(key string, expireAt *int64, update bool) ([]byte, bool, error) {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	item, ok := store.liveItemLocked(key)
+	if !ok {
+		return nil, false, nil
+	}
+
+	b, ok := store.shareStringLocked(key, &item)
+	if !ok {
+		return nil, false, ERRWrongType
+	}
+
+	if update {
+		item.expireAt = expireAt
+		item.modCounter++
+		store.putLocked(key, item)
+		store.trackExpireLocked(key, expireAt)
+	}
+	return b, true, nil
+}
+
+// CombineStrings calls fn with the strings at keys, nil for missing keys, and
+// stores the result at dest without an expiry, all under one lock. An empty
+// result deletes dest instead, and CombineStrings reports whether there was a
+// dest to delete.
+func (store *Store) CombineStrings(dest string, keys []string, fn func(vals [][]byte) []byte) ([]byte, bool, error) {
+	store.dataMu.Lock()
+	defer store.dataMu.Unlock()
+
+	vals := make([][]byte, len(keys))
+	for i, key := range keys {
+		item, ok := store.liveItemLocked(key)
+		if !ok {
+			continue
+		}
+
+		b, ok := store.shareStringLocked(key, &item)
+		if !ok {
+			return nil, false, ERRWrongType
+		}
+		vals[i] = b
+	}
+
+	val := fn(vals)
+	if len(val) == 0 {
+		_, existed := store.liveItemLocked(dest)
+		if existed {
+			store.deleteLocked(dest)
+		}
+		return val, existed, nil
+	}
+
+	store.putStringLocked(dest, val, nil)
+	return val, false, nil
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func setByte(i int, b byte) func(buf []byte) bool {
+	return func(buf []byte) bool {
+		buf[i] = b
+		return true
+	}
+}
+
+func TestMutateStringInPlace(t *testing.T) {
+	st := NewStore()
+
+	var first, second *byte
+	if _, err := st.MutateString("bits", 100, func(buf []byte) bool {
+		first = &buf[0]
+		return true
+	}); err != nil {
+		t.Fatalf("MutateString() error = %v", err)
+	}
+	if _, err := st.MutateString("bits", 150, func(buf []byte) bool {
+		second = &buf[0]
+		buf[149] = 1
+		return true
+	}); err != nil {
+		t.Fatalf("MutateString() error = %v", err)
+	}
+	if first != second {
+		t.Error("growing within the preallocated room copied the buffer")
+	}
+
+	got, _, _ := st.GetString("bits")
+	if len(got) != 150 || got[149] != 1 || bytes.Count(got, []byte{0}) != 149 {
+		t.Errorf("GetString() = %v, want 149 zero bytes and a 1", got)
+	}
+}
+
+func TestMutateStringCopiesHandedOut(t *testing.T) {
+	st := NewStore()
+	st.MutateString("bits", 4, setByte(0, 'a'))
+
+	got, _, _ := st.GetString("bits")
+	snapshot := st.Snapshot()[0].Value.([]byte)
+	st.MutateString("bits", 4, setByte(0, 'b'))
+	if got[0] != 'a' {
+		t.Errorf("GetString() result changed to %q by a later MutateString", got)
+	}
+
+	snapshot2 := st.Snapshot()[0].Value.([]byte)
+	st.MutateString("bits", 4, setByte(0, 'c'))
+	if snapshot[0] != 'a' || snapshot2[0] != 'b' {
+		t.Errorf("snapshots changed to %q and %q by a later MutateString", snapshot, snapshot2)
+	}
+
+	if got, _, _ := st.GetString("bits"); got[0] != 'c' {
+		t.Errorf("GetString() = %q, want it to start with c", got)
+	}
+}
+
+func TestMutateStringKeepsValueAndExpiry(t *testing.T) {
+	st := NewStore()
+	expireAt := time.Now().Add(time.Hour).UnixMilli()
+	if _, err := st.SetString("n", "12", SetOptions{ExpireAt: &expireAt}); err != nil {
+		t.Fatalf("SetString() error = %v", err)
+	}
+
+	written, err := st.MutateString("n", 3, func(buf []byte) bool { return false })
+	if err != nil || !written {
+		t.Fatalf("MutateString() = %v, %v, want a write padding the value", written, err)
+	}
+	if got, _, _ := st.GetString("n"); !bytes.Equal(got, []byte("12\x00")) {
+		t.Errorf("GetString() = %q, want %q", got, "12\x00")
+	}
+	if got, _ := st.GetExpire("n"); got == nil || *got != expireAt {
+		t.Errorf("GetExpire() = %v, want %d", got, expireAt)
+	}
+
+	if written, _ := st.MutateString("n", 1, func(buf []byte) bool { return false }); written {
+		t.Error("MutateString() wrote a value fn left unchanged")
+	}
+	if want := int64(keyOverhead + len("n") + 3); st.MemoryUsage() != want {
+		t.Errorf("MemoryUsage() = %d, want %d", st.MemoryUsage(), want)
+	}
+
+	st.Set("list", NewList(), List, nil)
+	if _, err := st.MutateString("list", 1, setByte(0, 1)); err != ERRWrongType {
+		t.Errorf("MutateString() on a list error = %v, want ERRWrongType", err)
+	}
+}
@@ -0,0 +1,58 @@
+package ringbuffer
+
+// RingBuffer keeps the last Cap() bytes written to it, overwriting the
+// oldest ones once full.
+type RingBuffer struct {
+	buf  []byte
+	next int
+	size int
+}
+
+func New(capacity int) *RingBuffer {
+	return &RingBuffer{
+		buf: make([]byte, capacity),
+	}
+}
+
+func (r *RingBuffer) Write(p []byte) {
+	capacity := len(r.buf)
+	if capacity == 0 {
+		return
+	}
+
+	if len(p) >= capacity {
+		copy(r.buf, p[len(p)-capacity:])
+		r.next = 0
+		r.size = capacity
+		return
+	}
+
+	n := copy(r.buf[r.next:], p)
+	copy(r.buf, p[n:])
+	r.next = (r.next + len(p)) % capacity
+	r.size = min(r.size+len(p), capacity)
+}
+
+// Tail returns a copy of the last n bytes written. It returns false if fewer
+// than n bytes are held.
+func (r *RingBuffer) Tail(n int) ([]byte, bool) {
+	if n < 0 || n > r.size {
+		return nil, false
+	}
+
+	out := make([]byte, n)
+	start := (r.next - n + len(r.buf)) % max(len(r.buf), 1)
+	c := copy(out, r.buf[start:])
+	if c < n {
+		copy(out[c:], r.buf[:n-c])
+	}
+	return out, true
+}
+
+func (r *RingBuffer) Len() int {
+	return r.size
+}
+
+func (r *RingBuffer) Cap() int {
+	return len(r.buf)
+}
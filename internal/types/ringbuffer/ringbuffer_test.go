@@ -0,0 +1,60 @@
+package ringbuffer
+
+import (
+	"testing"
+)
+
+func TestRingBufferTail(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		writes   []string
+		tail     int
+		expect   string
+		ok       bool
+	}{
+		{"empty", 4, nil, 0, "", true},
+		{"within capacity", 8, []string{"abc", "de"}, 5, "abcde", true},
+		{"partial tail", 8, []string{"abc", "de"}, 2, "de", true},
+		{"more than held", 8, []string{"abc"}, 4, "", false},
+		{"wraps around", 4, []string{"abc", "def"}, 4, "cdef", true},
+		{"wrapped partial tail", 4, []string{"abc", "def"}, 3, "def", true},
+		{"oversized write", 4, []string{"a", "bcdefgh"}, 4, "efgh", true},
+		{"many small writes", 3, []string{"a", "b", "c", "d", "e"}, 3, "cde", true},
+		{"zero capacity", 0, []string{"abc"}, 0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(tt.capacity)
+			for _, w := range tt.writes {
+				r.Write([]byte(w))
+			}
+
+			got, ok := r.Tail(tt.tail)
+			if ok != tt.ok {
+				t.Fatalf("Tail(%d) ok = %v, want %v", tt.tail, ok, tt.ok)
+			}
+			if ok && string(got) != tt.expect {
+				t.Errorf("Tail(%d) = %q, want %q", tt.tail, got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestRingBufferLen(t *testing.T) {
+	r := New(5)
+	if r.Len() != 0 || r.Cap() != 5 {
+		t.Fatalf("new buffer: Len() = %d, Cap() = %d", r.Len(), r.Cap())
+	}
+
+	r.Write([]byte("abc"))
+	if r.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", r.Len())
+	}
+
+	r.Write([]byte("defg"))
+	if r.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", r.Len())
+	}
+}
@@ -0,0 +1,100 @@
+package scantable
+
+import (
+	"hash/maphash"
+	"math/bits"
+)
+
+const minSize = 4
+
+// Table keeps a set of strings in a power of two number of hash buckets so
+// it can be walked with the reverse binary cursor used by Redis' SCAN. Every
+// element present for a whole iteration is returned at least once, even when
+// the table grows or shrinks between calls.
+type Table struct {
+	seed    maphash.Seed
+	buckets [][]string
+	count   int
+}
+
+func New() *Table {
+	return &Table{
+		seed:    maphash.MakeSeed(),
+		buckets: make([][]string, minSize),
+	}
+}
+
+func (t *Table) Len() int {
+	return t.count
+}
+
+// Add inserts s and reports whether it was not present yet.
+func (t *Table) Add(s string) bool {
+	i := t.bucket(s)
+	for _, e := range t.buckets[i] {
+		if e == s {
+			return false
+		}
+	}
+
+	t.buckets[i] = append(t.buckets[i], s)
+	t.count++
+	if t.count > len(t.buckets) {
+		t.resize(len(t.buckets) * 2)
+	}
+	return true
+}
+
+// Remove deletes s and reports whether it was present.
+func (t *Table) Remove(s string) bool {
+	i := t.bucket(s)
+	b := t.buckets[i]
+	for j, e := range b {
+		if e != s {
+			continue
+		}
+
+		b[j] = b[len(b)-1]
+		b[len(b)-1] = ""
+		t.buckets[i] = b[:len(b)-1]
+		t.count--
+		// INFO: like Redis, only shrink once the table is under 1/8 full
+		if len(t.buckets) > minSize && t.count*8 < len(t.buckets) {
+			t.resize(len(t.buckets) / 2)
+		}
+		return true
+	}
+	return false
+}
+
+// Scan calls fn for every element of the bucket the cursor points at and
+// returns the cursor to continue from, 0 once the iteration is complete.
+func (t *Table) Scan(cursor uint64, fn func(s string)) uint64 {
+	mask := uint64(len(t.buckets) - 1)
+	for _, e := range t.buckets[cursor&mask] {
+		fn(e)
+	}
+
+	// INFO: increment the reversed cursor, so that the high bits change
+	// first. Buckets already visited in a smaller or larger table then map
+	// to buckets that were visited as well.
+	cursor |= ^mask
+	cursor = bits.Reverse64(cursor)
+	cursor++
+	return bits.Reverse64(cursor)
+}
+
+func (t *Table) bucket(s string) uint64 {
+	return maphash.String(t.seed, s) & uint64(len(t.buckets)-1)
+}
+
+func (t *Table) resize(size int) {
+	old := t.buckets
+	t.buckets = make([][]string, size)
+	for _, b := range old {
+		for _, e := range b {
+			i := t.bucket(e)
+			t.buckets[i] = append(t.buckets[i], e)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package scantable
+
+import (
+	"strconv"
+	"testing"
+)
+
+func scanAll(t *Table, cursor uint64, steps int, seen map[string]int) uint64 {
+	for i := 0; i < steps || steps < 0; i++ {
+		cursor = t.Scan(cursor, func(s string) {
+			seen[s]++
+		})
+		if cursor == 0 {
+			break
+		}
+	}
+	return cursor
+}
+
+func TestTableAddRemove(t *testing.T) {
+	tb := New()
+	for i := range 100 {
+		if !tb.Add(strconv.Itoa(i)) {
+			t.Fatalf("Add(%d) reported existing element", i)
+		}
+	}
+	if tb.Add("7") {
+		t.Fatal("Add of existing element reported new")
+	}
+	if tb.Len() != 100 {
+		t.Fatalf("Len() = %d, want 100", tb.Len())
+	}
+
+	for i := range 95 {
+		if !tb.Remove(strconv.Itoa(i)) {
+			t.Fatalf("Remove(%d) reported missing element", i)
+		}
+	}
+	if tb.Remove("0") {
+		t.Fatal("Remove of missing element reported present")
+	}
+	if tb.Len() != 5 {
+		t.Fatalf("Len() = %d, want 5", tb.Len())
+	}
+	if len(tb.buckets) >= 128 {
+		t.Fatalf("table did not shrink, %d buckets", len(tb.buckets))
+	}
+}
+
+func TestTableScanFull(t *testing.T) {
+	tb := New()
+	for i := range 1000 {
+		tb.Add(strconv.Itoa(i))
+	}
+
+	seen := make(map[string]int)
+	scanAll(tb, 0, -1, seen)
+
+	if len(seen) != 1000 {
+		t.Fatalf("scan returned %d distinct elements, want 1000", len(seen))
+	}
+	for s, n := range seen {
+		if n != 1 {
+			t.Errorf("%q returned %d times without resizing", s, n)
+		}
+	}
+}
+
+func TestTableScanAcrossResize(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(tb *Table)
+	}{
+		{"grow", func(tb *Table) {
+			for i := range 5000 {
+				tb.Add("new" + strconv.Itoa(i))
+			}
+		}},
+		{"shrink", func(tb *Table) {
+			for i := 100; i < 1000; i++ {
+				tb.Remove("tmp" + strconv.Itoa(i))
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := New()
+			for i := range 100 {
+				tb.Add("keep" + strconv.Itoa(i))
+			}
+			for i := 100; i < 1000; i++ {
+				tb.Add("tmp" + strconv.Itoa(i))
+			}
+
+			seen := make(map[string]int)
+			cursor := scanAll(tb, 0, 100, seen)
+			if cursor == 0 {
+				t.Fatal("scan finished before the table was resized")
+			}
+			tt.mutate(tb)
+			scanAll(tb, cursor, -1, seen)
+
+			for i := range 100 {
+				if seen["keep"+strconv.Itoa(i)] == 0 {
+					t.Errorf("keep%d was never returned", i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package bitutil
+
+import "math/bits"
+
+// INFO: bits are numbered like Redis does it, from the most significant bit
+// of the first byte, so bit 0 is the 0x80 bit of b[0]
+
+// GetBit returns the bit at offset, bits past the end of b being 0.
+func GetBit(b []byte, offset uint64) int {
+	byteIdx := offset >> 3
+	if byteIdx >= uint64(len(b)) {
+		return 0
+	}
+	return int(b[byteIdx]>>(7-offset&7)) & 1
+}
+
+// SetBit sets the bit at offset in b, which must be long enough to hold it,
+// and returns the previous bit.
+func SetBit(b []byte, offset uint64, bit int) int {
+	byteIdx, mask := offset>>3, byte(0x80)>>(offset&7)
+	old := 0
+	if b[byteIdx]&mask != 0 {
+		old = 1
+	}
+
+	if bit == 1 {
+		b[byteIdx] |= mask
+	} else {
+		b[byteIdx] &^= mask
+	}
+	return old
+}
+
+// Count returns the number of set bits between the bit offsets first and
+// last, both inclusive and within b.
+func Count(b []byte, first, last uint64) int64 {
+	if first > last {
+		return 0
+	}
+
+	firstByte, lastByte := first>>3, last>>3
+	// INFO: mask out the bits before first and after last
+	headMask := byte(0xff) >> (first & 7)
+	tailMask := byte(0xff) << (7 - last&7)
+
+	if firstByte == lastByte {
+		return int64(bits.OnesCount8(b[firstByte] & headMask & tailMask))
+	}
+
+	n := bits.OnesCount8(b[firstByte]&headMask) + bits.OnesCount8(b[lastByte]&tailMask)
+	for _, c := range b[firstByte+1 : lastByte] {
+		n += bits.OnesCount8(c)
+	}
+	return int64(n)
+}
+
+// Pos returns the offset of the first bit equal to bit between the bit
+// offsets first and last, both inclusive and within b, or -1.
+func Pos(b []byte, bit int, first, last uint64) int64 {
+	// INFO: skip whole bytes that cannot contain the bit
+	var skip byte
+	if bit == 0 {
+		skip = 0xff
+	}
+
+	for i := first; i <= last; i++ {
+		if i&7 == 0 && i+7 <= last && b[i>>3] == skip {
+			i += 7
+			continue
+		}
+		if GetBit(b, i) == bit {
+			return int64(i)
+		}
+	}
+	return -1
+}
+
+// Op is a BITOP operation.
+type Op int
+
+const (
+	OpAnd Op = iota
+	OpOr
+	OpXor
+	OpNot
+)
+
+// Apply combines srcs byte by byte, shorter sources being padded with zero
+// bytes. OpNot uses only the first source.
+func Apply(op Op, srcs [][]byte) []byte {
+	size := 0
+	for _, src := range srcs {
+		size = max(size, len(src))
+	}
+
+	res := make([]byte, size)
+	if op == OpNot {
+		for i, c := range srcs[0] {
+			res[i] = ^c
+		}
+		return res
+	}
+
+	for i := range res {
+		var acc byte
+		for j, src := range srcs {
+			var c byte
+			if i < len(src) {
+				c = src[i]
+			}
+
+			switch {
+			case j == 0:
+				acc = c
+			case op == OpAnd:
+				acc &= c
+			case op == OpOr:
+				acc |= c
+			case op == OpXor:
+				acc ^= c
+			}
+		}
+		res[i] = acc
+	}
+	return res
+}
@@ -0,0 +1,159 @@
+package bitutil
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestGetSetBit(t *testing.T) {
+	b := make([]byte, 2)
+	if old := SetBit(b, 1, 1); old != 0 {
+		t.Errorf("SetBit(1) returned %d, want 0", old)
+	}
+	if old := SetBit(b, 15, 1); old != 0 {
+		t.Errorf("SetBit(15) returned %d, want 0", old)
+	}
+	if !bytes.Equal(b, []byte{0x40, 0x01}) {
+		t.Fatalf("bits = %x, want 4001", b)
+	}
+	if old := SetBit(b, 1, 0); old != 1 {
+		t.Errorf("SetBit(1, 0) returned %d, want 1", old)
+	}
+
+	for offset, want := range map[uint64]int{0: 0, 1: 0, 15: 1, 16: 0, 1000: 0} {
+		if got := GetBit(b, offset); got != want {
+			t.Errorf("GetBit(%d) = %d, want %d", offset, got, want)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	b := []byte("foobar")
+	tests := []struct {
+		first, last uint64
+		want        int64
+	}{
+		{0, 47, 26},
+		{0, 7, 4},
+		{8, 15, 6},
+		{5, 30, 17},
+		{1, 1, 1},
+		{0, 0, 0},
+		{7, 6, 0},
+	}
+
+	for _, tt := range tests {
+		if got := Count(b, tt.first, tt.last); got != tt.want {
+			t.Errorf("Count(%d, %d) = %d, want %d", tt.first, tt.last, got, tt.want)
+		}
+	}
+}
+
+func TestPos(t *testing.T) {
+	tests := []struct {
+		b           []byte
+		bit         int
+		first, last uint64
+		want        int64
+	}{
+		{[]byte{0xff, 0xf0, 0x00}, 0, 0, 23, 12},
+		{[]byte{0x00, 0xff, 0xf0}, 1, 0, 23, 8},
+		{[]byte{0x00, 0x00, 0x00}, 1, 0, 23, -1},
+		{[]byte{0xff, 0xff}, 0, 0, 15, -1},
+		{[]byte{0x00, 0xff}, 1, 2, 5, -1},
+		{[]byte{0x10, 0xff}, 1, 2, 9, 3},
+	}
+
+	for _, tt := range tests {
+		if got := Pos(tt.b, tt.bit, tt.first, tt.last); got != tt.want {
+			t.Errorf("Pos(%x, %d, %d, %d) = %d, want %d", tt.b, tt.bit, tt.first, tt.last, got, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	a, b := []byte{0xf0, 0x0f}, []byte{0xff}
+	tests := []struct {
+		op   Op
+		srcs [][]byte
+		want []byte
+	}{
+		{OpAnd, [][]byte{a, b}, []byte{0xf0, 0x00}},
+		{OpOr, [][]byte{a, b}, []byte{0xff, 0x0f}},
+		{OpXor, [][]byte{a, b}, []byte{0x0f, 0x0f}},
+		{OpNot, [][]byte{a}, []byte{0x0f, 0xf0}},
+		{OpAnd, [][]byte{nil, nil}, []byte{}},
+	}
+
+	for _, tt := range tests {
+		if got := Apply(tt.op, tt.srcs); !bytes.Equal(got, tt.want) {
+			t.Errorf("Apply(%d) = %x, want %x", tt.op, got, tt.want)
+		}
+	}
+}
+
+func TestFieldGetSet(t *testing.T) {
+	b := make([]byte, 9)
+	fields := []struct {
+		f      Field
+		offset uint64
+		v      int64
+	}{
+		{Field{Bits: 5, Signed: true}, 3, -7},
+		{Field{Bits: 8}, 8, 200},
+		{Field{Bits: 64, Signed: true}, 8, math.MinInt64 + 5},
+		{Field{Bits: 1}, 71, 1},
+	}
+
+	for _, tt := range fields {
+		tt.f.Set(b, tt.offset, tt.v)
+		if got := tt.f.Get(b, tt.offset); got != tt.v {
+			t.Errorf("%+v at %d = %d, want %d", tt.f, tt.offset, got, tt.v)
+		}
+	}
+
+	if got := (Field{Bits: 16}).Get(b, 64); got != 0x0500 {
+		t.Errorf("read past the end = %#x, want 0x500", got)
+	}
+}
+
+func TestFieldAdd(t *testing.T) {
+	i8, u8 := Field{Bits: 8, Signed: true}, Field{Bits: 8}
+	i64, u63 := Field{Bits: 64, Signed: true}, Field{Bits: 63}
+	tests := []struct {
+		f       Field
+		v, incr int64
+		ov      Overflow
+		want    int64
+		ok      bool
+	}{
+		{i8, 100, 20, OverflowWrap, 120, true},
+		{i8, 100, 100, OverflowWrap, -56, true},
+		{i8, 100, 100, OverflowSat, 127, true},
+		{i8, 100, 100, OverflowFail, 0, false},
+		{i8, -100, -100, OverflowWrap, 56, true},
+		{i8, -100, -100, OverflowSat, -128, true},
+		{i8, -200, 0, OverflowSat, -128, true},
+		{u8, 250, 10, OverflowWrap, 4, true},
+		{u8, 250, 10, OverflowSat, 255, true},
+		{u8, 5, -10, OverflowSat, 0, true},
+		{u8, 5, -10, OverflowWrap, 251, true},
+		{u8, -1, 0, OverflowWrap, 255, true},
+		{u8, -1, 0, OverflowSat, 255, true},
+		{u8, -1, 0, OverflowFail, 0, false},
+		{u8, 256, 0, OverflowFail, 0, false},
+		{i64, math.MaxInt64, 1, OverflowWrap, math.MinInt64, true},
+		{i64, math.MinInt64, math.MinInt64, OverflowSat, math.MinInt64, true},
+		{i64, 1, math.MinInt64, OverflowFail, math.MinInt64 + 1, true},
+		{u63, math.MaxInt64, 1, OverflowWrap, 0, true},
+		{u63, 0, math.MinInt64, OverflowFail, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.f.Add(tt.v, tt.incr, tt.ov)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%+v Add(%d, %d, %d) = %d, %v, want %d, %v", tt.f, tt.v, tt.incr, tt.ov, got, ok, tt.want, tt.ok)
+		}
+	}
+}
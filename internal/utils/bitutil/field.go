@@ -0,0 +1,95 @@
+package bitutil
+
+import "math"
+
+// Field is a BITFIELD integer type, like i5 or u8. Signed fields are up to
+// 64 bits wide, unsigned ones up to 63, so every value fits in an int64.
+type Field struct {
+	Bits   uint
+	Signed bool
+}
+
+// Overflow is the BITFIELD OVERFLOW behavior.
+type Overflow int
+
+const (
+	OverflowWrap Overflow = iota
+	OverflowSat
+	OverflowFail
+)
+
+// Get reads the field at the bit offset, bits past the end of b being 0.
+func (f Field) Get(b []byte, offset uint64) int64 {
+	var v uint64
+	for i := range uint64(f.Bits) {
+		v = v<<1 | uint64(GetBit(b, offset+i))
+	}
+
+	if f.Signed && f.Bits < 64 && v&(1<<(f.Bits-1)) != 0 {
+		v |= math.MaxUint64 << f.Bits
+	}
+	return int64(v)
+}
+
+// Set writes v to the field at the bit offset. b must be long enough to hold
+// the field.
+func (f Field) Set(b []byte, offset uint64, v int64) {
+	for i := range uint64(f.Bits) {
+		SetBit(b, offset+i, int(uint64(v)>>(uint64(f.Bits)-1-i))&1)
+	}
+}
+
+// Add returns v+incr handled according to ov, v being either a value read
+// from the field or, for SET, the value to store with incr 0. It reports
+// false when ov is OverflowFail and the result does not fit.
+func (f Field) Add(v, incr int64, ov Overflow) (int64, bool) {
+	var lo, hi int64
+	if f.Signed {
+		hi = int64(uint64(1)<<(f.Bits-1) - 1)
+		lo = -hi - 1
+	} else {
+		hi = int64(uint64(1)<<f.Bits - 1)
+	}
+
+	// INFO: written so the checks themselves cannot overflow for any v and
+	// incr, as long as v is within the field range whenever incr is not 0
+	var overflow, underflow bool
+	if f.Signed {
+		overflow = incr >= 0 && v > hi-incr
+		underflow = (incr < 0 && v < lo-incr) || (incr >= 0 && v < lo)
+	} else {
+		// INFO: like Redis, v is taken as a uint64, so setting a negative
+		// value overflows past the top of the range rather than below 0
+		overflow = uint64(v) > uint64(hi) || (incr > 0 && v > hi-incr)
+		underflow = !overflow && incr < 0 && v+incr < 0
+	}
+
+	if !overflow && !underflow {
+		return v + incr, true
+	}
+
+	switch ov {
+	case OverflowSat:
+		if overflow {
+			return hi, true
+		}
+		return lo, true
+	case OverflowFail:
+		return 0, false
+	default:
+		return f.wrap(uint64(v) + uint64(incr)), true
+	}
+}
+
+// wrap truncates v to the field width, sign extending signed fields.
+func (f Field) wrap(v uint64) int64 {
+	if f.Bits == 64 {
+		return int64(v)
+	}
+
+	v &= 1<<f.Bits - 1
+	if f.Signed && v&(1<<(f.Bits-1)) != 0 {
+		v |= math.MaxUint64 << f.Bits
+	}
+	return int64(v)
+}
@@ -0,0 +1,119 @@
+package globutil
+
+// Match reports whether s matches the glob-style pattern the way Redis'
+// KEYS and SCAN MATCH do. It supports '?', '*', '[...]' sets with ranges and
+// '^' negation, and '\' to escape the next character.
+func Match(pattern, s string) bool {
+	skipLonger := false
+	return match([]byte(pattern), []byte(s), &skipLonger, 0)
+}
+
+// maxNesting bounds the recursion, one level per '*', like Redis does
+// against abusive patterns.
+const maxNesting = 1000
+
+// match is Redis' stringmatchlen_impl. Once the rest of the pattern after a
+// '*' matched nowhere in the rest of the string, skipLonger is set so the
+// '*'s before it give up too: letting them match more only leaves less of
+// the string for that same rest. Without it "a*a*a*...b" takes exponential
+// time on a long run of "a"s.
+func match(p, str []byte, skipLonger *bool, nesting int) bool {
+	if nesting > maxNesting {
+		return false
+	}
+
+	for len(p) > 0 && len(str) > 0 {
+		switch p[0] {
+		case '*':
+			for len(p) > 1 && p[1] == '*' {
+				p = p[1:]
+			}
+			if len(p) == 1 {
+				return true
+			}
+			for len(str) > 0 {
+				if match(p[1:], str, skipLonger, nesting+1) {
+					return true
+				}
+				if *skipLonger {
+					return false
+				}
+				str = str[1:]
+			}
+			*skipLonger = true
+			return false
+		case '?':
+			str = str[1:]
+		case '[':
+			var matched bool
+			p, matched = matchSet(p[1:], str[0])
+			if !matched {
+				return false
+			}
+			str = str[1:]
+		case '\\':
+			if len(p) >= 2 {
+				p = p[1:]
+			}
+			fallthrough
+		default:
+			if p[0] != str[0] {
+				return false
+			}
+			str = str[1:]
+		}
+
+		p = p[1:]
+	}
+
+	if len(str) == 0 {
+		for len(p) > 0 && p[0] == '*' {
+			p = p[1:]
+		}
+	}
+
+	return len(p) == 0 && len(str) == 0
+}
+
+// matchSet matches c against the set starting right after '['. It returns
+// the pattern positioned at the closing ']', or at its last byte when the set
+// is not terminated.
+func matchSet(p []byte, c byte) ([]byte, bool) {
+	not := len(p) > 0 && p[0] == '^'
+	if not {
+		p = p[1:]
+	}
+
+	match := false
+	for {
+		switch {
+		case len(p) == 0:
+			// INFO: unterminated set, the caller still needs a byte to skip
+			return []byte{']'}, match != not
+		case p[0] == '\\' && len(p) >= 2:
+			p = p[1:]
+			if p[0] == c {
+				match = true
+			}
+		case p[0] == ']':
+			return p, match != not
+		case len(p) >= 3 && p[1] == '-':
+			start, end := p[0], p[2]
+			if start > end {
+				start, end = end, start
+			}
+			if c >= start && c <= end {
+				match = true
+			}
+			p = p[2:]
+		default:
+			if p[0] == c {
+				match = true
+			}
+		}
+		if len(p) == 1 {
+			return p, match != not
+		}
+		p = p[1:]
+	}
+}
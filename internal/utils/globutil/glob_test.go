@@ -0,0 +1,76 @@
+package globutil
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"*", "", true},
+		{"*", "anything", true},
+		{"", "", true},
+		{"", "a", false},
+		{"hello", "hello", true},
+		{"hello", "hell", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h*llo", "hllo", true},
+		{"h*llo", "heeeello", true},
+		{"h*llo", "heeeelo", false},
+		{"*llo", "hello", true},
+		{"he*", "hello", true},
+		{"he**", "he", true},
+		{"*a*b*", "xaxxbx", true},
+		{"*a*b*", "xbxxax", false},
+		{"h[ae]llo", "hello", true},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{"h[a-b]llo", "hcllo", false},
+		{"h[b-a]llo", "hallo", true},
+		{"key:[0-9]*", "key:1abc", true},
+		{"key:[0-9]*", "key:abc", false},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{`h\?llo`, "hello", false},
+		{`[\]]`, "]", true},
+		{`[\-]`, "-", true},
+		{`foo\`, `foo\`, true},
+		{"[abc", "a", true},
+		{"[abc", "c", true},
+		{"[abc", "d", false},
+		{"a[", "a", false},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+// TestMatchPathological checks that a pattern with many '*'s that cannot
+// match does not backtrack through every way of splitting the string.
+func TestMatchPathological(t *testing.T) {
+	pattern, s := strings.Repeat("a*", 12)+"b", strings.Repeat("a", 40)
+
+	done := make(chan bool, 1)
+	go func() { done <- Match(pattern, s) }()
+
+	select {
+	case got := <-done:
+		if got {
+			t.Errorf("Match(%q, %q) = true, want false", pattern, s)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Match(%q, %q) did not return within a second", pattern, s)
+	}
+}